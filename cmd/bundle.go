@@ -0,0 +1,124 @@
+// ABOUTME: Logic for cat on a directory: fetches many files as one bundle.
+// ABOUTME: Plans files within a byte budget, fetches them with a worker pool and prints them with headers.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bartriepe/my-docs/forge"
+)
+
+// BundleWorkers is how many files a bundle fetches at once.
+const BundleWorkers = 8
+
+// PlanBundle picks the files of entries to fetch, in order. Only blobs that
+// include matches are considered, or all blobs when include is empty. When
+// maxBytes is positive, files that would take the bundle past it are skipped
+// and returned separately so they can be read on their own. Files of unknown
+// size count as empty here; FetchBundle holds them to the budget as they
+// arrive.
+func PlanBundle(entries []forge.TreeEntry, include *GlobSet, maxBytes int64) (files, skipped []forge.TreeEntry) {
+	filter := include != nil && !include.Empty()
+	var total int64
+	for _, e := range entries {
+		if e.Type != "blob" || (filter && !include.Match(e.Path)) {
+			continue
+		}
+		size := max(e.Size, 0)
+		if maxBytes > 0 && total+size > maxBytes {
+			skipped = append(skipped, e)
+			continue
+		}
+		total += size
+		files = append(files, e)
+	}
+	return files, skipped
+}
+
+// BundleFile is one fetched file of a bundle.
+type BundleFile struct {
+	Path    string
+	Content string
+	Err     error
+}
+
+// FetchBundle fetches paths with at most workers requests in flight and
+// returns the files in the order of paths. When maxBytes is positive the
+// fetched content counts against it too, since hosts that do not list sizes
+// leave PlanBundle nothing to count: once it passes maxBytes no more fetches
+// start, and the file that crossed it and all after it are returned as
+// skipped.
+func FetchBundle(paths []string, workers int, maxBytes int64, fetch func(path string) (string, error)) (files []BundleFile, skipped []string) {
+	fetched := make([]BundleFile, len(paths))
+	done := make([]bool, len(paths))
+	jobs := make(chan int)
+	var fetchedBytes atomic.Int64
+
+	var wg sync.WaitGroup
+	for range min(workers, len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if maxBytes > 0 && fetchedBytes.Load() > maxBytes {
+					continue
+				}
+				content, err := fetch(paths[i])
+				fetched[i] = BundleFile{Path: paths[i], Content: content, Err: err}
+				done[i] = true
+				fetchedBytes.Add(int64(len(content)))
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var total int64
+	for i, f := range fetched {
+		if maxBytes > 0 && (!done[i] || total+int64(len(f.Content)) > maxBytes) {
+			return files, paths[i:]
+		}
+		total += int64(len(f.Content))
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// FormatBundle prints each file under a "==> path <==" header, as head and
+// tail do for several files. Binary files and failed fetches get a note in
+// place of their content, and skipped files are listed at the end.
+func FormatBundle(files []BundleFile, skipped []string, maxBytes int64) string {
+	var sb strings.Builder
+	for i, f := range files {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("==> %s <==\n", f.Path))
+		switch {
+		case f.Err != nil:
+			sb.WriteString(fmt.Sprintf("(error: %v)\n", f.Err))
+		case strings.Contains(f.Content, "\x00"):
+			sb.WriteString(fmt.Sprintf("(binary file, %s, not shown)\n", FormatSize(int64(len(f.Content)))))
+		default:
+			sb.WriteString(f.Content)
+			if f.Content != "" && !strings.HasSuffix(f.Content, "\n") {
+				sb.WriteString("\n")
+			}
+		}
+	}
+
+	if len(skipped) > 0 {
+		sb.WriteString(fmt.Sprintf("\n... skipped %d files to stay within --max-bytes %d:\n", len(skipped), maxBytes))
+		for _, path := range skipped {
+			sb.WriteString("  " + path + "\n")
+		}
+	}
+	return sb.String()
+}
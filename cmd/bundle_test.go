@@ -0,0 +1,132 @@
+// ABOUTME: Tests for fetching a directory as a bundle.
+// ABOUTME: Verifies byte budgets, bounded concurrent fetching and bundle formatting.
+
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bartriepe/my-docs/forge"
+)
+
+func TestPlanBundle(t *testing.T) {
+	entries := []forge.TreeEntry{
+		{Path: "a.md", Type: "blob", Size: 40},
+		{Path: "img", Type: "tree"},
+		{Path: "img/logo.png", Type: "blob", Size: 10},
+		{Path: "b.md", Type: "blob", Size: 70},
+		{Path: "c.md", Type: "blob", Size: 50},
+	}
+	include, err := NewGlobSet([]string{"*.md"})
+	if err != nil {
+		t.Fatalf("NewGlobSet() error = %v", err)
+	}
+
+	files, skipped := PlanBundle(entries, include, 100)
+	if len(files) != 2 || files[0].Path != "a.md" || files[1].Path != "c.md" {
+		t.Errorf("PlanBundle() files = %+v, want a.md and c.md", files)
+	}
+	if len(skipped) != 1 || skipped[0].Path != "b.md" {
+		t.Errorf("PlanBundle() skipped = %+v, want b.md", skipped)
+	}
+
+	all, _ := PlanBundle(entries, nil, 0)
+	if len(all) != 4 {
+		t.Errorf("PlanBundle() without limits = %d files, want 4", len(all))
+	}
+}
+
+func TestFetchBundle(t *testing.T) {
+	var inFlight, peak int32
+	fetch := func(path string) (string, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		if path == "bad" {
+			return "", errors.New("boom")
+		}
+		return "content of " + path, nil
+	}
+
+	paths := []string{"1", "2", "bad", "4", "5", "6", "7"}
+	files, skipped := FetchBundle(paths, 3, 0, fetch)
+
+	if peak > 3 {
+		t.Errorf("FetchBundle() ran %d fetches at once, want at most 3", peak)
+	}
+	for i, f := range files {
+		if f.Path != paths[i] {
+			t.Errorf("FetchBundle()[%d].Path = %q, want %q", i, f.Path, paths[i])
+		}
+	}
+	if files[2].Err == nil || files[3].Content != "content of 4" {
+		t.Errorf("FetchBundle() = %+v, want an error for bad and content for the rest", files)
+	}
+	if len(skipped) != 0 {
+		t.Errorf("FetchBundle() without a budget skipped %v", skipped)
+	}
+}
+
+func TestFetchBundle_UnknownSizes(t *testing.T) {
+	// GitLab and plain git hosts list no sizes, so planning lets every file
+	// through and the budget has to hold while fetching.
+	entries := []forge.TreeEntry{
+		{Path: "a.md", Type: "blob", Size: -1},
+		{Path: "b.md", Type: "blob", Size: -1},
+		{Path: "c.md", Type: "blob", Size: -1},
+		{Path: "d.md", Type: "blob", Size: -1},
+	}
+	planned, skipped := PlanBundle(entries, nil, 100)
+	if len(planned) != 4 || len(skipped) != 0 {
+		t.Fatalf("PlanBundle() = %d files, %d skipped, want 4 and 0", len(planned), len(skipped))
+	}
+	var paths []string
+	for _, e := range planned {
+		paths = append(paths, e.Path)
+	}
+
+	var calls atomic.Int32
+	fetch := func(path string) (string, error) {
+		calls.Add(1)
+		return strings.Repeat("x", 40), nil
+	}
+	files, late := FetchBundle(paths, 1, 100, fetch)
+	if len(files) != 2 || files[1].Path != "b.md" {
+		t.Errorf("FetchBundle() = %d files, want a.md and b.md", len(files))
+	}
+	if len(late) != 2 || late[0] != "c.md" || late[1] != "d.md" {
+		t.Errorf("FetchBundle() skipped = %v, want [c.md d.md]", late)
+	}
+	// c.md takes the total past 100 and is fetched, but d.md never is.
+	if n := calls.Load(); n != 3 {
+		t.Errorf("FetchBundle() fetched %d files, want 3", n)
+	}
+}
+
+func TestFormatBundle(t *testing.T) {
+	files := []BundleFile{
+		{Path: "docs/a.md", Content: "# A\n"},
+		{Path: "docs/b.md", Content: "no newline"},
+		{Path: "docs/logo.png", Content: "\x89PNG\x00\x00"},
+		{Path: "docs/gone.md", Err: errors.New("HTTP 500")},
+	}
+	want := "==> docs/a.md <==\n# A\n" +
+		"\n==> docs/b.md <==\nno newline\n" +
+		"\n==> docs/logo.png <==\n(binary file, 6 B, not shown)\n" +
+		"\n==> docs/gone.md <==\n(error: HTTP 500)\n" +
+		"\n... skipped 1 files to stay within --max-bytes 100:\n  docs/big.md\n"
+
+	if got := FormatBundle(files, []string{"docs/big.md"}, 100); got != want {
+		t.Errorf("FormatBundle() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,152 @@
+// ABOUTME: Logic for the cat command.
+// ABOUTME: Parses line ranges and streams the selected lines of a file, optionally numbered.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Slice selects the part of a file cat prints. The line range is applied
+// first, then Head and then Tail. Zero values select everything.
+type Slice struct {
+	// Lines is an inclusive range; a zero Start means from the first line and
+	// a zero End means to the last.
+	Lines LineRange
+	Head  int
+	Tail  int
+	// Number prefixes each line with its line number, matching the numbers
+	// search prints.
+	Number bool
+}
+
+// Whole reports whether the slice selects the whole file unchanged.
+func (s Slice) Whole() bool {
+	return s.Lines == LineRange{} && s.Head == 0 && s.Tail == 0 && !s.Number
+}
+
+var pathRangeRe = regexp.MustCompile(`^(.+):(\d+)(-(\d*))?$`)
+
+// ParsePathRange splits a "path:400-460", "path:400-" or "path:412" argument
+// into the path and its line range. ok is false when arg has no range suffix.
+func ParsePathRange(arg string) (path string, r LineRange, ok bool, err error) {
+	m := pathRangeRe.FindStringSubmatch(arg)
+	if m == nil {
+		return arg, LineRange{}, false, nil
+	}
+	if m[3] == "" {
+		r, err = ParseLineRange(m[2] + ":" + m[2])
+	} else {
+		r, err = ParseLineRange(m[2] + ":" + m[4])
+	}
+	return m[1], r, true, err
+}
+
+// ParseLineRange parses "400:460", "400:", ":460" or "400-460" into an
+// inclusive line range.
+func ParseLineRange(s string) (LineRange, error) {
+	startText, endText, ok := strings.Cut(s, ":")
+	if !ok {
+		startText, endText, ok = strings.Cut(s, "-")
+	}
+	if !ok {
+		return LineRange{}, fmt.Errorf("invalid line range %q: must be START:END", s)
+	}
+
+	var r LineRange
+	var err error
+	if startText != "" {
+		if r.Start, err = strconv.Atoi(startText); err != nil || r.Start < 1 {
+			return LineRange{}, fmt.Errorf("invalid line range %q: start must be a positive line number", s)
+		}
+	}
+	if endText != "" {
+		if r.End, err = strconv.Atoi(endText); err != nil || r.End < 1 {
+			return LineRange{}, fmt.Errorf("invalid line range %q: end must be a positive line number", s)
+		}
+	}
+	if r.End != 0 && r.End < max(r.Start, 1) {
+		return LineRange{}, fmt.Errorf("invalid line range %q: end is before start", s)
+	}
+	return r, nil
+}
+
+// WriteSlice copies the lines of r selected by s to w. It stops reading as
+// soon as no later line can be selected, so large files need not be read in
+// full.
+func WriteSlice(w io.Writer, r io.Reader, s Slice) error {
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+
+	type numbered struct {
+		n    int
+		text string
+	}
+	// tail holds the last s.Tail lines read as a ring: once full, oldest is
+	// where the next line goes.
+	var tail []numbered
+	oldest := 0
+
+	emit := func(n int, text string) error {
+		if s.Number {
+			if _, err := fmt.Fprintf(bw, "%6d\t", n); err != nil {
+				return err
+			}
+		}
+		_, err := bw.WriteString(text)
+		return err
+	}
+
+	selected := 0
+	for n := 1; ; n++ {
+		if s.Lines.End != 0 && n > s.Lines.End {
+			break
+		}
+		if s.Head != 0 && selected == s.Head {
+			break
+		}
+
+		text, err := br.ReadString('\n')
+		if text == "" && err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+
+		if n >= s.Lines.Start {
+			selected++
+			switch {
+			case s.Tail == 0:
+				if werr := emit(n, text); werr != nil {
+					return werr
+				}
+			case len(tail) < s.Tail:
+				tail = append(tail, numbered{n, text})
+			default:
+				tail[oldest] = numbered{n, text}
+				oldest = (oldest + 1) % len(tail)
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, l := range slices.Concat(tail[oldest:], tail[:oldest]) {
+		if err := emit(l.n, l.text); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
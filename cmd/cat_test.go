@@ -0,0 +1,125 @@
+// ABOUTME: Tests for the cat command logic.
+// ABOUTME: Verifies line range parsing and slicing with head, tail and numbering.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePathRange(t *testing.T) {
+	tests := []struct {
+		arg    string
+		path   string
+		r      LineRange
+		ranged bool
+	}{
+		{"docs/intro.md", "docs/intro.md", LineRange{}, false},
+		{"main.go:400-460", "main.go", LineRange{Start: 400, End: 460}, true},
+		{"main.go:412", "main.go", LineRange{Start: 412, End: 412}, true},
+		{"main.go:400-", "main.go", LineRange{Start: 400}, true},
+		{"c:/odd:name.txt", "c:/odd:name.txt", LineRange{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			path, r, ok, err := ParsePathRange(tt.arg)
+			if err != nil {
+				t.Fatalf("ParsePathRange() error = %v", err)
+			}
+			if path != tt.path || r != tt.r || ok != tt.ranged {
+				t.Errorf("ParsePathRange() = %q, %+v, %v, want %q, %+v, %v", path, r, ok, tt.path, tt.r, tt.ranged)
+			}
+		})
+	}
+
+	if _, _, _, err := ParsePathRange("main.go:460-400"); err == nil {
+		t.Error("ParsePathRange() with end before start error = nil, want error")
+	}
+}
+
+func TestParseLineRange(t *testing.T) {
+	tests := []struct {
+		in   string
+		want LineRange
+	}{
+		{"400:460", LineRange{Start: 400, End: 460}},
+		{"400:", LineRange{Start: 400}},
+		{":20", LineRange{End: 20}},
+		{"5-9", LineRange{Start: 5, End: 9}},
+	}
+	for _, tt := range tests {
+		got, err := ParseLineRange(tt.in)
+		if err != nil {
+			t.Fatalf("ParseLineRange(%q) error = %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("ParseLineRange(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"400", "0:5", "a:b", "9:5"} {
+		if _, err := ParseLineRange(bad); err == nil {
+			t.Errorf("ParseLineRange(%q) error = nil, want error", bad)
+		}
+	}
+}
+
+func TestWriteSlice(t *testing.T) {
+	const file = "one\ntwo\nthree\nfour\nfive"
+
+	tests := []struct {
+		name  string
+		slice Slice
+		want  string
+	}{
+		{"whole", Slice{}, file},
+		{"range", Slice{Lines: LineRange{Start: 2, End: 3}}, "two\nthree\n"},
+		{"open range", Slice{Lines: LineRange{Start: 4}}, "four\nfive"},
+		{"head", Slice{Head: 2}, "one\ntwo\n"},
+		{"tail", Slice{Tail: 2}, "four\nfive"},
+		{"range then tail", Slice{Lines: LineRange{Start: 1, End: 4}, Tail: 1}, "four\n"},
+		{"numbered tail", Slice{Tail: 3, Number: true}, "     3\tthree\n     4\tfour\n     5\tfive"},
+		{"tail longer than file", Slice{Tail: 9}, file},
+		{"numbered", Slice{Lines: LineRange{Start: 2, End: 3}, Number: true}, "     2\ttwo\n     3\tthree\n"},
+		{"past end", Slice{Lines: LineRange{Start: 9}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			if err := WriteSlice(&sb, strings.NewReader(file), tt.slice); err != nil {
+				t.Fatalf("WriteSlice() error = %v", err)
+			}
+			if sb.String() != tt.want {
+				t.Errorf("WriteSlice() = %q, want %q", sb.String(), tt.want)
+			}
+		})
+	}
+}
+
+// stopReader fails if it is read past its content, to check that WriteSlice
+// stops once the range is done.
+type stopReader struct {
+	r *strings.Reader
+	t *testing.T
+}
+
+func (s stopReader) Read(p []byte) (int, error) {
+	if s.r.Len() == 0 {
+		s.t.Error("WriteSlice() read past the end of the range")
+	}
+	return s.r.Read(p)
+}
+
+func TestWriteSlice_StopsEarly(t *testing.T) {
+	var sb strings.Builder
+	r := stopReader{strings.NewReader("a\nb\nc\n"), t}
+	if err := WriteSlice(&sb, r, Slice{Head: 1}); err != nil {
+		t.Fatalf("WriteSlice() error = %v", err)
+	}
+	if sb.String() != "a\n" {
+		t.Errorf("WriteSlice() = %q, want %q", sb.String(), "a\n")
+	}
+}
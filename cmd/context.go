@@ -0,0 +1,146 @@
+// ABOUTME: Builds grep-style context blocks around search matches.
+// ABOUTME: Merges overlapping line ranges and reports when snippets lack the needed lines.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bartriepe/my-docs/grepapp"
+)
+
+// LineRange is an inclusive range of 1-based line numbers.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// ContextRanges returns the ranges covering before and after lines around each
+// matched line, merging ranges that overlap or touch.
+func ContextRanges(matches []int, before, after int) []LineRange {
+	sorted := append([]int(nil), matches...)
+	sort.Ints(sorted)
+
+	var ranges []LineRange
+	for _, line := range sorted {
+		r := LineRange{Start: max(1, line-before), End: line + after}
+		if n := len(ranges); n > 0 && r.Start <= ranges[n-1].End+1 {
+			ranges[n-1].End = max(ranges[n-1].End, r.End)
+			continue
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// FileContext collects what is known about the lines of one matched file.
+type FileContext struct {
+	Repo    string
+	Branch  string
+	Path    string
+	Matches []int
+	Lines   map[int]string
+	Ranges  map[int][]grepapp.Range
+	AlsoIn  []string
+	// Complete is set once Lines holds the whole file, so lines missing from
+	// it are past the end of the file.
+	Complete bool
+}
+
+// BuildFileContexts groups the displayed match lines by file, in order of first
+// appearance, and fills each file's known lines from the snippet rows of hits.
+func BuildFileContexts(matches []MatchLine, hits []grepapp.Hit) []*FileContext {
+	var files []*FileContext
+	byKey := make(map[string]*FileContext)
+	for _, m := range matches {
+		key := m.Repo + "\x00" + m.Path
+		fc, ok := byKey[key]
+		if !ok {
+			fc = &FileContext{
+				Repo:   m.Repo,
+				Branch: m.Branch,
+				Path:   m.Path,
+				Lines:  make(map[int]string),
+				Ranges: make(map[int][]grepapp.Range),
+				AlsoIn: m.AlsoIn,
+			}
+			byKey[key] = fc
+			files = append(files, fc)
+		}
+		fc.Matches = append(fc.Matches, m.Line)
+		fc.Ranges[m.Line] = m.Ranges
+	}
+
+	for _, hit := range hits {
+		fc, ok := byKey[hit.Repo+"\x00"+hit.Path]
+		if !ok {
+			continue
+		}
+		for _, l := range HitLines(hit) {
+			fc.Lines[l.Line] = l.Text
+		}
+	}
+	return files
+}
+
+// Missing reports whether any line needed for the given context is unknown.
+func (fc *FileContext) Missing(before, after int) bool {
+	if fc.Complete {
+		return false
+	}
+	for _, r := range ContextRanges(fc.Matches, before, after) {
+		for n := r.Start; n <= r.End; n++ {
+			if _, ok := fc.Lines[n]; !ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetContent replaces the known lines with the full file content. Lines are
+// trimmed the same way snippet rows are, so match ranges still line up.
+func (fc *FileContext) SetContent(content string) {
+	fc.Lines = make(map[int]string)
+	for i, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		fc.Lines[i+1] = strings.TrimSpace(line)
+	}
+	fc.Complete = true
+}
+
+// FormatContext prints each file's matches with surrounding lines in grep's
+// context format: "path:N: text" for matches, "path-N- text" for context, and
+// "--" between non-adjacent blocks. Unknown lines are skipped.
+func FormatContext(files []*FileContext, before, after int, opts FormatOptions) string {
+	var sb strings.Builder
+	first := true
+	for _, fc := range files {
+		matched := make(map[int]bool)
+		for _, n := range fc.Matches {
+			matched[n] = true
+		}
+
+		for _, r := range ContextRanges(fc.Matches, before, after) {
+			if !first {
+				sb.WriteString("--\n")
+			}
+			first = false
+			for n := r.Start; n <= r.End; n++ {
+				text, ok := fc.Lines[n]
+				if !ok {
+					continue
+				}
+				if !matched[n] {
+					sb.WriteString(fmt.Sprintf("%s-%d- %s\n", opts.location(fc.Repo, fc.Path), n, text))
+					continue
+				}
+				ranges := fc.Ranges[n]
+				sb.WriteString(fmt.Sprintf("%s:%s: %s\n", opts.location(fc.Repo, fc.Path), opts.lineNumber(n, ranges), opts.text(text, ranges)))
+			}
+		}
+		sb.WriteString(formatAlsoIn(fc.AlsoIn))
+	}
+	return sb.String()
+}
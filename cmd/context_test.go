@@ -0,0 +1,114 @@
+// ABOUTME: Tests for grep-style context blocks.
+// ABOUTME: Verifies range merging, missing-line detection and output format.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bartriepe/my-docs/grepapp"
+)
+
+func TestContextRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		matches []int
+		before  int
+		after   int
+		want    []LineRange
+	}{
+		{name: "single match", matches: []int{10}, before: 2, after: 2, want: []LineRange{{8, 12}}},
+		{name: "clipped at first line", matches: []int{2}, before: 3, after: 0, want: []LineRange{{1, 2}}},
+		{name: "overlapping merged", matches: []int{10, 13}, before: 2, after: 2, want: []LineRange{{8, 15}}},
+		{name: "adjacent merged", matches: []int{10, 15}, before: 2, after: 2, want: []LineRange{{8, 17}}},
+		{name: "separate blocks", matches: []int{10, 20}, before: 1, after: 1, want: []LineRange{{9, 11}, {19, 21}}},
+		{name: "unsorted input", matches: []int{20, 10}, before: 0, after: 0, want: []LineRange{{10, 10}, {20, 20}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ContextRanges(tt.matches, tt.before, tt.after)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ContextRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildFileContexts(t *testing.T) {
+	hits := []grepapp.Hit{
+		snippetHit("a/b", "one.go", "first", "<mark>second</mark>", "third"),
+		snippetHit("a/b", "two.go", "<mark>only</mark>"),
+	}
+	matches := []MatchLine{
+		{Repo: "a/b", Path: "one.go", Line: 2, Text: "second", Matched: true},
+	}
+
+	files := BuildFileContexts(matches, hits)
+
+	if len(files) != 1 {
+		t.Fatalf("BuildFileContexts() returned %d files, want 1", len(files))
+	}
+	fc := files[0]
+	if fc.Path != "one.go" || !reflect.DeepEqual(fc.Matches, []int{2}) {
+		t.Errorf("BuildFileContexts()[0] = %+v, want one.go with match on line 2", fc)
+	}
+	if fc.Lines[1] != "first" || fc.Lines[3] != "third" {
+		t.Errorf("BuildFileContexts()[0].Lines = %v, want snippet rows", fc.Lines)
+	}
+	if fc.Missing(1, 1) {
+		t.Error("Missing(1, 1) = true, want false when snippet covers context")
+	}
+	if !fc.Missing(0, 2) {
+		t.Error("Missing(0, 2) = false, want true when context runs past snippet")
+	}
+
+	fc.SetContent("first\n  second\nthird\n")
+	if fc.Missing(0, 5) {
+		t.Error("Missing() = true after SetContent, want false")
+	}
+	if fc.Lines[2] != "second" {
+		t.Errorf("Lines[2] = %q after SetContent, want %q", fc.Lines[2], "second")
+	}
+}
+
+func TestFormatContext(t *testing.T) {
+	files := []*FileContext{
+		{
+			Path:    "one.go",
+			Matches: []int{2, 9},
+			Lines:   map[int]string{1: "a", 2: "b", 3: "c", 8: "h", 9: "i", 10: "j"},
+		},
+		{
+			Path:    "two.go",
+			Matches: []int{1},
+			Lines:   map[int]string{1: "x", 2: "y"},
+		},
+	}
+
+	got := FormatContext(files, 1, 1, FormatOptions{})
+	want := "one.go-1- a\n" +
+		"one.go:2: b\n" +
+		"one.go-3- c\n" +
+		"--\n" +
+		"one.go-8- h\n" +
+		"one.go:9: i\n" +
+		"one.go-10- j\n" +
+		"--\n" +
+		"two.go:1: x\n" +
+		"two.go-2- y\n"
+	if got != want {
+		t.Errorf("FormatContext() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormatContext_ShowRepo(t *testing.T) {
+	files := []*FileContext{{Repo: "a/b", Path: "one.go", Matches: []int{2}, Lines: map[int]string{1: "a", 2: "b"}}}
+
+	got := FormatContext(files, 1, 0, FormatOptions{ShowRepo: true})
+	want := "a/b:one.go-1- a\na/b:one.go:2: b\n"
+	if got != want {
+		t.Errorf("FormatContext() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,105 @@
+// ABOUTME: Collapses identical search hits found in forks and vendored copies.
+// ABOUTME: Fingerprints hits by match text and path suffix and keeps the top-ranked repo.
+
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bartriepe/my-docs/grepapp"
+)
+
+// suffixDepth is how many trailing path components identify a file across
+// copies: "vendor/github.com/x/y/pkg/file.go" and "pkg/file.go" agree on two.
+const suffixDepth = 2
+
+// maxAlsoIn is how many duplicate repos a result names before summarising.
+const maxAlsoIn = 3
+
+// Fingerprint identifies a hit's content independent of the repo it is in:
+// the trailing path components plus the whitespace-normalised text of the
+// matched lines.
+func Fingerprint(hit grepapp.Hit) string {
+	rows := hit.Matches()
+	var texts []string
+	for _, m := range rows {
+		if m.Matched {
+			texts = append(texts, strings.Join(strings.Fields(m.Text), " "))
+		}
+	}
+	if len(texts) == 0 {
+		for _, m := range rows {
+			texts = append(texts, strings.Join(strings.Fields(m.Text), " "))
+		}
+	}
+	return pathSuffix(hit.Path, suffixDepth) + "\x00" + strings.Join(texts, "\n")
+}
+
+func pathSuffix(path string, depth int) string {
+	parts := strings.Split(path, "/")
+	if len(parts) > depth {
+		parts = parts[len(parts)-depth:]
+	}
+	return strings.Join(parts, "/")
+}
+
+// merge folds hit into an earlier hit with the same fingerprint from another
+// repo, keeping whichever copy ranks higher as the one shown. It returns false
+// if hit is not a duplicate and should be kept as its own result.
+func (c *Collector) merge(hit grepapp.Hit) bool {
+	fp := Fingerprint(hit)
+	i, ok := c.groups[fp]
+	if !ok {
+		if c.groups == nil {
+			c.groups = make(map[string]int)
+		}
+		c.groups[fp] = len(c.Hits)
+		return false
+	}
+
+	shown := c.Hits[i]
+	if shown.Repo == hit.Repo || slices.Contains(c.alsoIn[i], hit.Repo) {
+		// Another file in a repo we already have is not a fork or copy.
+		return false
+	}
+
+	if c.rank(hit.Repo) > c.rank(shown.Repo) {
+		c.Hits[i] = hit
+		c.lines[i] = c.hitLines(hit)
+		c.alsoIn[i] = append(c.alsoIn[i], shown.Repo)
+	} else {
+		c.alsoIn[i] = append(c.alsoIn[i], hit.Repo)
+	}
+	sort.SliceStable(c.alsoIn[i], func(a, b int) bool {
+		return c.rank(c.alsoIn[i][a]) > c.rank(c.alsoIn[i][b])
+	})
+	return true
+}
+
+func (c *Collector) rank(repo string) int {
+	if c.Rank == nil {
+		return 0
+	}
+	return c.Rank(repo)
+}
+
+// formatAlsoIn is the note printed under a result with duplicates elsewhere.
+func formatAlsoIn(repos []string) string {
+	if len(repos) == 0 {
+		return ""
+	}
+	noun := "repos"
+	if len(repos) == 1 {
+		noun = "repo"
+	}
+	names := repos
+	more := ""
+	if len(names) > maxAlsoIn {
+		names = names[:maxAlsoIn]
+		more = ", ..."
+	}
+	return fmt.Sprintf("  (also in %d %s: %s%s)\n", len(repos), noun, strings.Join(names, ", "), more)
+}
@@ -0,0 +1,98 @@
+// ABOUTME: Tests for collapsing duplicate hits across repos.
+// ABOUTME: Verifies fingerprints, representative selection and the also-in note.
+
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bartriepe/my-docs/grepapp"
+)
+
+func TestFingerprint(t *testing.T) {
+	upstream := snippetHit("serde-rs/serde", "serde/src/de/mod.rs", "context", "<mark>fn  deserialize</mark>")
+	vendored := snippetHit("someone/app", "vendor/serde/src/de/mod.rs", "other context", "<mark>fn deserialize</mark>")
+	elsewhere := snippetHit("someone/app", "src/de/other.rs", "<mark>fn deserialize</mark>")
+
+	if Fingerprint(upstream) != Fingerprint(vendored) {
+		t.Error("Fingerprint() differs for a vendored copy with the same matched text and path suffix")
+	}
+	if Fingerprint(upstream) == Fingerprint(elsewhere) {
+		t.Error("Fingerprint() equal for a different file name")
+	}
+}
+
+func TestCollector_Dedupe(t *testing.T) {
+	ranks := map[string]int{"serde-rs/serde": 500, "fork-a/serde": 20, "fork-b/serde": 10}
+	src := &fakeSource{batches: [][]grepapp.Hit{
+		{
+			snippetHit("fork-a/serde", "src/lib.rs", "<mark>pub trait Serialize</mark>"),
+			snippetHit("fork-a/serde", "src/other.rs", "<mark>unrelated</mark>"),
+		},
+		{
+			snippetHit("serde-rs/serde", "src/lib.rs", "<mark>pub trait Serialize</mark>"),
+			snippetHit("fork-b/serde", "src/lib.rs", "<mark>pub trait Serialize</mark>"),
+		},
+	}}
+	c := &Collector{
+		Source: src,
+		Dedupe: true,
+		Rank:   func(repo string) int { return ranks[repo] },
+	}
+
+	lines, err := c.Lines(10)
+	if err != nil {
+		t.Fatalf("Lines() error = %v", err)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("Lines() returned %d lines, want 2: %+v", len(lines), lines)
+	}
+	if lines[0].Repo != "serde-rs/serde" {
+		t.Errorf("Lines()[0].Repo = %q, want the highest-ranked copy serde-rs/serde", lines[0].Repo)
+	}
+	if want := []string{"fork-a/serde", "fork-b/serde"}; !reflect.DeepEqual(lines[0].AlsoIn, want) {
+		t.Errorf("Lines()[0].AlsoIn = %v, want %v", lines[0].AlsoIn, want)
+	}
+	if lines[1].Path != "src/other.rs" || len(lines[1].AlsoIn) != 0 {
+		t.Errorf("Lines()[1] = %+v, want unduplicated src/other.rs", lines[1])
+	}
+}
+
+func TestCollector_DedupeKeepsSameRepoFiles(t *testing.T) {
+	src := &fakeSource{batches: [][]grepapp.Hit{{
+		snippetHit("a/b", "one/util.go", "<mark>x</mark>"),
+		snippetHit("a/b", "two/one/util.go", "<mark>x</mark>"),
+	}}}
+	c := &Collector{Source: src, Dedupe: true}
+
+	lines, err := c.Lines(10)
+	if err != nil {
+		t.Fatalf("Lines() error = %v", err)
+	}
+	if len(lines) != 2 {
+		t.Errorf("Lines() returned %d lines, want 2 for two files in one repo", len(lines))
+	}
+}
+
+func TestFormatLines_AlsoIn(t *testing.T) {
+	lines := []MatchLine{
+		{Repo: "serde-rs/serde", Path: "src/lib.rs", Line: 1, Text: "a", AlsoIn: []string{"x/1", "x/2", "x/3", "x/4"}},
+		{Repo: "serde-rs/serde", Path: "src/lib.rs", Line: 2, Text: "b", AlsoIn: []string{"x/1", "x/2", "x/3", "x/4"}},
+		{Repo: "other/repo", Path: "main.go", Line: 5, Text: "c"},
+	}
+
+	got := FormatLines(lines, FormatOptions{ShowRepo: true})
+	want := "serde-rs/serde:src/lib.rs:1: a\n" +
+		"serde-rs/serde:src/lib.rs:2: b\n" +
+		"  (also in 4 repos: x/1, x/2, x/3, ...)\n" +
+		"other/repo:main.go:5: c\n"
+	if got != want {
+		t.Errorf("FormatLines() =\n%s\nwant\n%s", got, want)
+	}
+
+	if note := formatAlsoIn([]string{"x/1"}); !strings.Contains(note, "also in 1 repo: x/1") {
+		t.Errorf("formatAlsoIn() = %q, want singular form", note)
+	}
+}
@@ -0,0 +1,73 @@
+// ABOUTME: Logic for the diff command.
+// ABOUTME: Compares two tree listings to find the files added, removed or changed between refs.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bartriepe/my-docs/forge"
+)
+
+// TreeChange is a file that differs between two listings. Status is "A" for
+// added, "D" for removed and "M" for changed, as in git diff --name-status.
+type TreeChange struct {
+	Status string
+	Path   string
+}
+
+// DiffTrees compares the files and submodules of two listings by object ID
+// and returns the changes sorted by path.
+func DiffTrees(from, to []forge.TreeEntry) []TreeChange {
+	files := func(entries []forge.TreeEntry) map[string]forge.TreeEntry {
+		m := make(map[string]forge.TreeEntry)
+		for _, e := range entries {
+			if e.Type != "tree" {
+				m[e.Path] = e
+			}
+		}
+		return m
+	}
+	before, after := files(from), files(to)
+
+	var changes []TreeChange
+	for p, old := range before {
+		cur, ok := after[p]
+		switch {
+		case !ok:
+			changes = append(changes, TreeChange{Status: "D", Path: p})
+		case old.SHA != cur.SHA || old.Type != cur.Type:
+			changes = append(changes, TreeChange{Status: "M", Path: p})
+		}
+	}
+	for p := range after {
+		if _, ok := before[p]; !ok {
+			changes = append(changes, TreeChange{Status: "A", Path: p})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// FormatStat lists changes one per line with their status, followed by a
+// summary of how many files were added, removed and changed.
+func FormatStat(changes []TreeChange) string {
+	counts := make(map[string]int)
+	var sb strings.Builder
+	for _, c := range changes {
+		sb.WriteString(fmt.Sprintf("%s  %s\n", c.Status, c.Path))
+		counts[c.Status]++
+	}
+	sb.WriteString(fmt.Sprintf("%d %s changed: %d added, %d removed, %d modified\n",
+		len(changes), plural(len(changes), "file", "files"), counts["A"], counts["D"], counts["M"]))
+	return sb.String()
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
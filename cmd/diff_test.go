@@ -0,0 +1,41 @@
+// ABOUTME: Tests for the diff command logic.
+// ABOUTME: Verifies tree comparison by object ID and the --stat summary.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/bartriepe/my-docs/forge"
+)
+
+func TestDiffTrees(t *testing.T) {
+	from := []forge.TreeEntry{
+		{Path: "docs", Type: "tree", SHA: "d1"},
+		{Path: "docs/old.md", Type: "blob", SHA: "o1"},
+		{Path: "docs/same.md", Type: "blob", SHA: "s1"},
+		{Path: "README.md", Type: "blob", SHA: "r1"},
+	}
+	to := []forge.TreeEntry{
+		{Path: "docs", Type: "tree", SHA: "d2"},
+		{Path: "docs/new.md", Type: "blob", SHA: "n1"},
+		{Path: "docs/same.md", Type: "blob", SHA: "s1"},
+		{Path: "README.md", Type: "blob", SHA: "r2"},
+	}
+
+	got := DiffTrees(from, to)
+	want := []TreeChange{{"M", "README.md"}, {"A", "docs/new.md"}, {"D", "docs/old.md"}}
+	if len(got) != len(want) {
+		t.Fatalf("DiffTrees() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("DiffTrees()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	wantStat := "M  README.md\nA  docs/new.md\nD  docs/old.md\n3 files changed: 1 added, 1 removed, 1 modified\n"
+	if stat := FormatStat(got); stat != wantStat {
+		t.Errorf("FormatStat() = %q, want %q", stat, wantStat)
+	}
+}
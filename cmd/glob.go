@@ -0,0 +1,140 @@
+// ABOUTME: Gitignore-style path globs for filtering search results and listings.
+// ABOUTME: Provides the vendor and test presets used by search exclusions.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// VendorGlobs matches vendored dependencies and generated code.
+var VendorGlobs = []string{
+	"vendor/",
+	"node_modules/",
+	"third_party/",
+	"third-party/",
+	"bower_components/",
+	"Godeps/",
+	"*.pb.go",
+	"zz_generated*",
+	"*.min.js",
+}
+
+// TestGlobs matches tests and test fixtures.
+var TestGlobs = []string{
+	"test/",
+	"tests/",
+	"testdata/",
+	"__tests__/",
+	"fixtures/",
+	"__fixtures__/",
+	"*_test.go",
+	"test_*.py",
+	"*_test.py",
+	"*.test.*",
+	"*.spec.*",
+}
+
+// GlobSet matches paths against a list of globs. A pattern without a slash
+// matches any file or directory name ("*.md", "testdata"); a trailing slash
+// limits it to directories ("vendor/"); a pattern with a slash elsewhere is
+// anchored at the repo root ("docs/*.md"). "**" matches across directories.
+// A nil GlobSet matches nothing.
+type GlobSet struct {
+	patterns []*regexp.Regexp
+}
+
+func NewGlobSet(globs []string) (*GlobSet, error) {
+	g := &GlobSet{}
+	for _, glob := range globs {
+		re, err := globRegexp(glob)
+		if err != nil {
+			return nil, err
+		}
+		g.patterns = append(g.patterns, re)
+	}
+	return g, nil
+}
+
+// Match reports whether path matches any glob in the set.
+func (g *GlobSet) Match(path string) bool {
+	if g == nil {
+		return false
+	}
+	for _, re := range g.patterns {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Empty reports whether the set has no globs.
+func (g *GlobSet) Empty() bool {
+	return g == nil || len(g.patterns) == 0
+}
+
+func globRegexp(glob string) (*regexp.Regexp, error) {
+	pattern := glob
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return nil, fmt.Errorf("invalid glob %q", glob)
+	}
+
+	body, err := translateGlob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %v", glob, err)
+	}
+
+	prefix := "^(?:.*/)?"
+	if anchored {
+		prefix = "^"
+	}
+	suffix := "(?:/.*)?$"
+	if dirOnly {
+		suffix = "/"
+	}
+	return regexp.Compile(prefix + body + suffix)
+}
+
+func translateGlob(pattern string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end == -1 {
+				return "", fmt.Errorf("unterminated character class")
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,90 @@
+// ABOUTME: Tests for gitignore-style path globs.
+// ABOUTME: Verifies name, directory and anchored pattern matching and presets.
+
+package cmd
+
+import "testing"
+
+func TestGlobSet_Match(t *testing.T) {
+	tests := []struct {
+		glob string
+		path string
+		want bool
+	}{
+		{"*.md", "README.md", true},
+		{"*.md", "docs/intro.md", true},
+		{"*.md", "docs/intro.mdx", false},
+		{"vendor/", "vendor/github.com/x/y.go", true},
+		{"vendor/", "pkg/vendor/x.go", true},
+		{"vendor/", "vendor.go", false},
+		{"vendor/", "pkg/vendor", false},
+		{"testdata", "pkg/testdata/in.txt", true},
+		{"testdata", "pkg/testdata", true},
+		{"docs/*.md", "docs/intro.md", true},
+		{"docs/*.md", "docs/sub/intro.md", false},
+		{"docs/*.md", "other/docs/intro.md", false},
+		{"docs/**/*.md", "docs/sub/deep/intro.md", true},
+		{"docs/**/*.md", "docs/intro.md", true},
+		{"/Makefile", "Makefile", true},
+		{"/Makefile", "sub/Makefile", false},
+		{"*_test.go", "pkg/x_test.go", true},
+		{"file?.go", "file1.go", true},
+		{"file[0-9].go", "file7.go", true},
+		{"file[!0-9].go", "file7.go", false},
+	}
+
+	for _, tt := range tests {
+		g, err := NewGlobSet([]string{tt.glob})
+		if err != nil {
+			t.Fatalf("NewGlobSet(%q) error = %v", tt.glob, err)
+		}
+		if got := g.Match(tt.path); got != tt.want {
+			t.Errorf("GlobSet(%q).Match(%q) = %v, want %v", tt.glob, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGlobSet_Invalid(t *testing.T) {
+	for _, glob := range []string{"", "/", "file[0-9"} {
+		if _, err := NewGlobSet([]string{glob}); err == nil {
+			t.Errorf("NewGlobSet(%q) error = nil, want error", glob)
+		}
+	}
+}
+
+func TestGlobSet_Nil(t *testing.T) {
+	var g *GlobSet
+	if g.Match("anything") {
+		t.Error("nil GlobSet.Match() = true, want false")
+	}
+	if !g.Empty() {
+		t.Error("nil GlobSet.Empty() = false, want true")
+	}
+}
+
+func TestPresets(t *testing.T) {
+	vendor, err := NewGlobSet(VendorGlobs)
+	if err != nil {
+		t.Fatalf("NewGlobSet(VendorGlobs) error = %v", err)
+	}
+	tests, err := NewGlobSet(TestGlobs)
+	if err != nil {
+		t.Fatalf("NewGlobSet(TestGlobs) error = %v", err)
+	}
+
+	for _, path := range []string{"vendor/a/b.go", "web/node_modules/x/index.js", "third_party/lib.c", "api/v1/types.pb.go"} {
+		if !vendor.Match(path) {
+			t.Errorf("VendorGlobs should match %q", path)
+		}
+	}
+	for _, path := range []string{"pkg/x_test.go", "tests/test_api.py", "src/app.spec.ts", "internal/testdata/in.json"} {
+		if !tests.Match(path) {
+			t.Errorf("TestGlobs should match %q", path)
+		}
+	}
+	for _, path := range []string{"pkg/server.go", "docs/testing.md", "src/app.ts"} {
+		if vendor.Match(path) || tests.Match(path) {
+			t.Errorf("presets should not match %q", path)
+		}
+	}
+}
@@ -39,8 +39,15 @@ Use ` + "`my-docs`" + ` when you need to:
 ### Available commands
 
 - ` + "`my-docs find <query>`" + ` - Search GitHub for repos matching query
-- ` + "`my-docs search [owner/repo] <pattern>`" + ` - Search repo contents (supports regex). Repo should be in owner/repo format, or omitted to search all repos
+- ` + "`my-docs search [owner/repo] <pattern>`" + ` - Search repo contents (supports regex). Repo should be in owner/repo format, a comma-separated list, owner/* for a whole org, or omitted to search all repos
 - ` + "`my-docs cat <owner/repo> <path>`" + ` - Fetch and display file contents
+- ` + "`my-docs ls <owner/repo> [dir]`" + ` - List a directory with file sizes
+- ` + "`my-docs tree <owner/repo> [dir] [--depth N] [--glob '*.md']`" + ` - Show the files under a directory as a tree
+- ` + "`my-docs releases <owner/repo> [--since V] [--until V]`" + ` - Show release notes, falling back to the repo's changelog
+- ` + "`my-docs log <owner/repo> [path] [--since DATE] [--patch]`" + ` - List the commits touching a file or directory
+- ` + "`my-docs diff <owner/repo> <path> <from> <to>`" + ` - Show how a file changed between two refs (` + "`--stat`" + ` for a directory)
+- ` + "`my-docs issues <owner/repo> <query> [--state open|closed] [--type issue|pr|discussion]`" + ` - Search a GitHub repo's issues and pull requests, or its discussions
+- ` + "`my-docs issue <owner/repo> <number>`" + ` - Read an issue, pull request or discussion with all its comments
 - ` + "`my-docs rust <crate> <symbol>`" + ` - Look up a Rust crate symbol and show its source
 
 ### Rust Crates
@@ -60,8 +67,27 @@ NEVER use ` + "`2>/dev/null`" + ` or ` + "`2>&1`" + ` when running ` + "`my-docs
 
 - Search specific repo: ` + "`my-docs search grafana/alloy \"exporter\"`" + `
 - Search all repos: ` + "`my-docs search \"specific_function_name\"`" + `
+- Skip vendored copies and tests: ` + "`my-docs search \"specific_function_name\" --no-vendor --no-tests`" + `
+- Search several repos or a whole org: ` + "`my-docs search grafana/alloy,grafana/loki \"exporter\"`" + `, ` + "`my-docs search \"grafana/*\" \"exporter\"`" + `
+- Find the docs layout before reading: ` + "`my-docs tree grafana/alloy docs --depth 2 --glob '*.md'`" + `
 - Use cat to read docs: ` + "`my-docs cat grafana/alloy README.md`" + `
+- Read a whole directory at once (trailing slash): ` + "`my-docs cat grafana/alloy docs/sources/ --glob '*.md' --max-bytes 100000`" + `
+- Read just the lines around a search hit: ` + "`my-docs cat grafana/alloy main.go:400-460 -n`" + ` (or ` + "`--lines 400:460`" + `, ` + "`--head N`" + `, ` + "`--tail N`" + `)
+- Read the version you deploy with @ref (tag, branch or commit): ` + "`my-docs cat grafana/alloy@v1.4.0 README.md`" + `
+- Scope to one package of a monorepo with //subdir: ` + "`my-docs search grafana/alloy//docs/sources \"otelcol\"`" + `
+- Repos grep.app does not index are searched in a downloaded snapshot automatically; force it with ` + "`--local`" + `, or search an exact version with ` + "`my-docs search grafana/alloy@v1.4.0 \"exporter\"`" + `
+- GitLab, Gitea and Codeberg repos are named with their host: ` + "`my-docs cat gitlab.com/gitlab-org/cli README.md`" + `, ` + "`my-docs tree codeberg.org/forgejo/forgejo docs`" + `
+- Upgrading a dependency? Read what changed in between: ` + "`my-docs releases grafana/alloy --since v1.2.0 --until v1.5.0`" + `
+- Find when and why an API changed: ` + "`my-docs log grafana/alloy internal/component/otelcol/config.go --patch --limit 5`" + `, then read the file as it was with ` + "`my-docs cat grafana/alloy@<sha> <path>`" + `
+- Check for breaking changes between versions: ` + "`my-docs diff grafana/alloy docs v1.0.0 v1.4.0 --stat`" + `, then ` + "`my-docs diff grafana/alloy docs/sources/reference/config.md v1.0.0 v1.4.0`" + `
+- Check whether an error is a known bug: ` + "`my-docs issues grafana/alloy \"connection reset\" --state open`" + `, then ` + "`my-docs issue grafana/alloy <number>`" + ` for the discussion and workarounds
 - Regex patterns work: ` + "`my-docs search grafana/alloy \"func.*Start\"`" + `
+- Search literally instead of escaping regex characters: ` + "`my-docs search -F rust-lang/rust \"Vec<T>\"`" + `
+- Match case or whole words: ` + "`my-docs search -s -w grafana/alloy \"Start\"`" + `
+- Only need to know which file to read? ` + "`my-docs search -l grafana/alloy \"prometheus.exporter\"`" + ` (or ` + "`--count`" + `, ` + "`--group`" + `)
+- Show surrounding lines: ` + "`my-docs search -C 3 grafana/alloy \"func.*Start\"`" + `
+- Narrow results: ` + "`my-docs search grafana/alloy \"otelcol\" --lang Markdown --path docs/`" + `
+- See where a pattern appears before paging through hits: ` + "`my-docs search --facets \"otelcol\"`" + `
 `
 
 func UpdateClaudeMdSection(content, instructions string) string {
@@ -0,0 +1,86 @@
+// ABOUTME: Logic for the issues and issue commands.
+// ABOUTME: Formats issue search results with body excerpts and whole threads as plain text.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bartriepe/my-docs/github"
+)
+
+// excerptLen is how much of an issue's first comment search results show.
+const excerptLen = 200
+
+// htmlComment matches the hidden hints issue templates leave in bodies.
+var htmlComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// Excerpt flattens body onto one line, without HTML comments, and cuts it
+// to at most n runes.
+func Excerpt(body string, n int) string {
+	text := strings.Join(strings.Fields(htmlComment.ReplaceAllString(body, "")), " ")
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return strings.TrimRight(string(runes[:n]), " ") + "..."
+}
+
+// FormatIssues prints each issue, pull request or discussion's number,
+// state, kind and title, with an excerpt of its first comment beneath.
+func FormatIssues(issues []github.Issue) string {
+	var sb strings.Builder
+	for _, is := range issues {
+		sb.WriteString(fmt.Sprintf("#%d [%s %s] %s\n", is.Number, is.State, kind(is), is.Title))
+		if excerpt := Excerpt(is.Body, excerptLen); excerpt != "" {
+			sb.WriteString("    " + excerpt + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// FormatThread prints an issue, pull request or discussion and its comments,
+// oldest first, each under a line naming its author and date. Replies in a
+// discussion follow the comment they answer.
+func FormatThread(is github.Issue, comments []github.Comment) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("#%d %s [%s %s]\n%s\n", is.Number, is.Title, is.State, kind(is), is.HTMLURL))
+	writePost(&sb, is.User.Login, is.CreatedAt, is.Body)
+	for _, c := range comments {
+		author := c.User.Login
+		if c.Reply {
+			author += " (reply)"
+		}
+		writePost(&sb, author, c.CreatedAt, c.Body)
+	}
+	return sb.String()
+}
+
+func writePost(sb *strings.Builder, author string, created time.Time, body string) {
+	sb.WriteString(fmt.Sprintf("\n%s on %s:\n", author, created.Format(time.DateOnly)))
+	body = strings.TrimSpace(strings.ReplaceAll(body, "\r\n", "\n"))
+	if body == "" {
+		sb.WriteString("    (no text)\n")
+		return
+	}
+	for _, line := range strings.Split(body, "\n") {
+		if line == "" {
+			sb.WriteString("\n")
+			continue
+		}
+		sb.WriteString("    " + line + "\n")
+	}
+}
+
+func kind(is github.Issue) string {
+	if is.Discussion {
+		return "discussion"
+	}
+	if is.IsPR() {
+		return "pr"
+	}
+	return "issue"
+}
@@ -0,0 +1,92 @@
+// ABOUTME: Tests for the issues and issue command logic.
+// ABOUTME: Verifies body excerpts, search result listings and issue and discussion threads.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bartriepe/my-docs/github"
+)
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		body string
+		n    int
+		want string
+	}{
+		{"", 20, ""},
+		{"Short\r\n\r\nbody", 20, "Short body"},
+		{"<!-- Describe the bug -->\nIt crashes\n<!--\nsteps\n-->on start", 40, "It crashes on start"},
+		{"héllo wörld again", 11, "héllo wörld..."},
+		{"one two three", 4, "one..."},
+	}
+	for _, tt := range tests {
+		if got := Excerpt(tt.body, tt.n); got != tt.want {
+			t.Errorf("Excerpt(%q, %d) = %q, want %q", tt.body, tt.n, got, tt.want)
+		}
+	}
+}
+
+func testIssue(number int, title, state, body string, pr bool) github.Issue {
+	is := github.Issue{Number: number, Title: title, State: state, Body: body}
+	if pr {
+		is.PullRequest = &struct{}{}
+	}
+	return is
+}
+
+func TestFormatIssues(t *testing.T) {
+	issues := []github.Issue{
+		testIssue(12, "Crash on start", "open", "It crashes\nwhen started.", false),
+		testIssue(15, "Fix crash", "closed", "", true),
+	}
+	want := "#12 [open issue] Crash on start\n" +
+		"    It crashes when started.\n" +
+		"#15 [closed pr] Fix crash\n"
+	if got := FormatIssues(issues); got != want {
+		t.Errorf("FormatIssues() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatThread(t *testing.T) {
+	is := testIssue(12, "Crash on start", "open", "It crashes.\r\n\r\nEvery time.", false)
+	is.HTMLURL = "https://github.com/owner/repo/issues/12"
+	is.User.Login = "alice"
+	is.CreatedAt = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	var c github.Comment
+	c.User.Login = "bob"
+	c.CreatedAt = time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)
+
+	want := "#12 Crash on start [open issue]\n" +
+		"https://github.com/owner/repo/issues/12\n" +
+		"\nalice on 2024-05-01:\n" +
+		"    It crashes.\n" +
+		"\n" +
+		"    Every time.\n" +
+		"\nbob on 2024-05-02:\n" +
+		"    (no text)\n"
+	if got := FormatThread(is, []github.Comment{c}); got != want {
+		t.Errorf("FormatThread() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatThread_Discussion(t *testing.T) {
+	is := testIssue(7, "How do I reload?", "closed", "Is there a way?", false)
+	is.Discussion = true
+	is.HTMLURL = "https://github.com/owner/repo/discussions/7"
+	is.User.Login = "alice"
+	var c, r github.Comment
+	c.User.Login, c.Body = "bob", "Send SIGHUP."
+	r.User.Login, r.Body, r.Reply = "alice", "Thanks!", true
+
+	want := "#7 How do I reload? [closed discussion]\n" +
+		"https://github.com/owner/repo/discussions/7\n" +
+		"\nalice on 0001-01-01:\n    Is there a way?\n" +
+		"\nbob on 0001-01-01:\n    Send SIGHUP.\n" +
+		"\nalice (reply) on 0001-01-01:\n    Thanks!\n"
+	if got := FormatThread(is, []github.Comment{c, r}); got != want {
+		t.Errorf("FormatThread() = %q, want %q", got, want)
+	}
+}
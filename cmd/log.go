@@ -0,0 +1,76 @@
+// ABOUTME: Logic for the log command.
+// ABOUTME: Parses --since dates and formats commit listings with optional per-file patches.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bartriepe/my-docs/github"
+)
+
+// ParseSince parses a --since value: a date such as "2024-05-01" or an
+// RFC 3339 timestamp.
+func ParseSince(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q for --since: must be YYYY-MM-DD or an RFC 3339 timestamp", s)
+}
+
+// FormatCommit prints one line per commit: the full SHA, so it can be used
+// as a ref, the author date, the author and the subject.
+func FormatCommit(c github.Commit) string {
+	subject, _, _ := strings.Cut(c.Commit.Message, "\n")
+	return fmt.Sprintf("%s %s %s: %s\n", c.SHA, c.Commit.Author.Date.Format(time.DateOnly), c.Commit.Author.Name, strings.TrimSpace(subject))
+}
+
+// FormatPatch prints the diffs of the files in c under path, or of every
+// file when path is empty, in the style of git diff. When no file under path
+// is listed, which happens for commits changing more files than GitHub
+// lists, it says so rather than printing nothing.
+func FormatPatch(c github.Commit, path string) string {
+	var sb strings.Builder
+	for _, f := range c.Files {
+		if path != "" && !underPath(f.Filename, path) && !underPath(f.PreviousFilename, path) {
+			continue
+		}
+		from := f.Filename
+		if f.PreviousFilename != "" {
+			from = f.PreviousFilename
+		}
+		sb.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n", from, f.Filename))
+		switch f.Status {
+		case "added":
+			sb.WriteString(fmt.Sprintf("--- /dev/null\n+++ b/%s\n", f.Filename))
+		case "removed":
+			sb.WriteString(fmt.Sprintf("--- a/%s\n+++ /dev/null\n", from))
+		default:
+			sb.WriteString(fmt.Sprintf("--- a/%s\n+++ b/%s\n", from, f.Filename))
+		}
+		switch {
+		case f.Patch == "" && f.Status == "renamed":
+			sb.WriteString("(renamed without changes)\n")
+			continue
+		case f.Patch == "":
+			sb.WriteString("(no textual diff: binary file or diff too large)\n")
+			continue
+		}
+		sb.WriteString(strings.TrimSuffix(f.Patch, "\n") + "\n")
+	}
+	if path != "" && sb.Len() == 0 {
+		return fmt.Sprintf("(no changes to %s among the %d files GitHub lists for this commit)\n", path, len(c.Files))
+	}
+	return sb.String()
+}
+
+// underPath reports whether file is path itself or inside directory path.
+func underPath(file, path string) bool {
+	path = strings.TrimSuffix(path, "/")
+	return file != "" && (file == path || strings.HasPrefix(file, path+"/"))
+}
@@ -0,0 +1,106 @@
+// ABOUTME: Tests for the log command logic.
+// ABOUTME: Verifies date parsing, commit lines, patch filtering by path and paths missing from large commits.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bartriepe/my-docs/github"
+	"github.com/bartriepe/my-docs/httpretry"
+)
+
+func TestParseSince(t *testing.T) {
+	got, err := ParseSince("2024-05-01")
+	if err != nil || !got.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseSince(date) = %v, %v, want 2024-05-01", got, err)
+	}
+	if _, err := ParseSince("2024-05-01T12:00:00+02:00"); err != nil {
+		t.Errorf("ParseSince(RFC 3339) error = %v", err)
+	}
+	if _, err := ParseSince("last week"); err == nil {
+		t.Error("ParseSince(\"last week\") error = nil, want error")
+	}
+}
+
+func testCommit() github.Commit {
+	var c github.Commit
+	c.SHA = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+	c.Commit.Author.Name = "Ada"
+	c.Commit.Author.Date = time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	c.Commit.Message = "Rename the exporter option\n\nLonger explanation."
+	c.Files = []github.CommitFile{
+		{Filename: "docs/exporter.md", Status: "modified", Patch: "@@ -1 +1 @@\n-old\n+new"},
+		{Filename: "docs/new.md", PreviousFilename: "docs/old.md", Status: "renamed"},
+		{Filename: "main.go", Status: "added", Patch: "@@ -0,0 +1 @@\n+package main"},
+	}
+	return c
+}
+
+func TestFormatCommit(t *testing.T) {
+	want := "4b825dc642cb6eb9a060e54bf8d69288fbee4904 2024-05-01 Ada: Rename the exporter option\n"
+	if got := FormatCommit(testCommit()); got != want {
+		t.Errorf("FormatCommit() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatPatch(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{
+			path: "docs/exporter.md",
+			want: "diff --git a/docs/exporter.md b/docs/exporter.md\n--- a/docs/exporter.md\n+++ b/docs/exporter.md\n@@ -1 +1 @@\n-old\n+new\n",
+		},
+		{
+			path: "docs/old.md",
+			want: "diff --git a/docs/old.md b/docs/new.md\n--- a/docs/old.md\n+++ b/docs/new.md\n(renamed without changes)\n",
+		},
+		{
+			path: "main.go",
+			want: "diff --git a/main.go b/main.go\n--- /dev/null\n+++ b/main.go\n@@ -0,0 +1 @@\n+package main\n",
+		},
+		{path: "doc", want: "(no changes to doc among the 3 files GitHub lists for this commit)\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := FormatPatch(testCommit(), tt.path); got != tt.want {
+				t.Errorf("FormatPatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestFormatPatch_PathMissingFromLargeCommit(t *testing.T) {
+	// Every page is full, so GitHub stops listing at 3000 files, none of
+	// which is the path asked about.
+	client := httpretry.Client
+	defer func() { httpretry.Client = client }()
+	httpretry.Client = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var files []string
+		for n := range 300 {
+			files = append(files, fmt.Sprintf(`{"filename": "vendor/%s/%d.go", "patch": "@@ -1 +1 @@"}`, req.URL.Query().Get("page"), n))
+		}
+		body := fmt.Sprintf(`{"sha": "abc", "files": [%s]}`, strings.Join(files, ","))
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})}
+
+	c, err := github.FetchCommit("grafana/alloy", "abc")
+	if err != nil {
+		t.Fatalf("FetchCommit() error = %v", err)
+	}
+	want := "(no changes to docs/index.md among the 3000 files GitHub lists for this commit)\n"
+	if got := FormatPatch(*c, "docs/index.md"); got != want {
+		t.Errorf("FormatPatch() = %q, want %q", got, want)
+	}
+}
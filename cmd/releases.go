@@ -0,0 +1,310 @@
+// ABOUTME: Logic for the releases command.
+// ABOUTME: Compares version numbers, splits changelogs into versions and formats release notes.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bartriepe/my-docs/forge"
+)
+
+// ChangelogFiles are the files releases reads when a repo has no releases,
+// in the order they are tried.
+var ChangelogFiles = []string{"CHANGELOG.md", "CHANGES.md"}
+
+// versionPattern finds a version number in a tag or heading, such as the
+// "1.4.0-rc.1" in "component/v1.4.0-rc.1" or "## [1.4.0-rc.1] - 2024-05-01".
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?(?:-([0-9A-Za-z.]+))?`)
+
+// Version is a parsed version number. Missing minor or patch numbers are 0.
+type Version struct {
+	Major, Minor, Patch int
+	// Pre is the pre-release suffix, as in "rc.1" for "1.4.0-rc.1".
+	Pre string
+}
+
+// ParseVersion finds the first version number in s.
+func ParseVersion(s string) (Version, bool) {
+	m := versionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, false
+	}
+	var v Version
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	v.Patch, _ = strconv.Atoi(m[3])
+	v.Pre = m[4]
+	return v, true
+}
+
+// Compare returns -1, 0 or 1 as v sorts before, the same as or after o.
+// A pre-release sorts before its release, as in semantic versioning.
+func (v Version) Compare(o Version) int {
+	for _, d := range [][2]int{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if d[0] != d[1] {
+			return sign(d[0] - d[1])
+		}
+	}
+	switch {
+	case v.Pre == o.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case o.Pre == "":
+		return -1
+	}
+	return comparePre(v.Pre, o.Pre)
+}
+
+// comparePre orders pre-release suffixes field by field, numbers
+// numerically and below words.
+func comparePre(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range min(len(as), len(bs)) {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return sign(an - bn)
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		case as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return sign(len(as) - len(bs))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// VersionRange selects versions between Since and Until, both inclusive.
+// A nil bound is open.
+type VersionRange struct {
+	Since, Until *Version
+}
+
+// ParseVersionRange parses --since and --until values; either may be empty.
+func ParseVersionRange(since, until string) (VersionRange, error) {
+	var r VersionRange
+	for _, b := range []struct {
+		flag, value string
+		dst         **Version
+	}{{"--since", since, &r.Since}, {"--until", until, &r.Until}} {
+		if b.value == "" {
+			continue
+		}
+		v, ok := ParseVersion(b.value)
+		if !ok {
+			return VersionRange{}, fmt.Errorf("invalid version %q for %s", b.value, b.flag)
+		}
+		*b.dst = &v
+	}
+	return r, nil
+}
+
+// Open reports whether the range has no bounds.
+func (r VersionRange) Open() bool {
+	return r.Since == nil && r.Until == nil
+}
+
+// Contains reports whether the version in s is within the range. Names
+// without a version, such as "Unreleased", only match an open range.
+func (r VersionRange) Contains(s string) bool {
+	if r.Open() {
+		return true
+	}
+	v, ok := ParseVersion(s)
+	if !ok {
+		return false
+	}
+	return (r.Since == nil || v.Compare(*r.Since) >= 0) && (r.Until == nil || v.Compare(*r.Until) <= 0)
+}
+
+// Passed reports whether page, a page of releases newest first, shows that
+// the releases after it are older than the range: it holds the --since
+// version itself, or only older versions. One old version among newer ones,
+// such as a backported patch, is not enough.
+func (r VersionRange) Passed(page []forge.Release) bool {
+	if r.Since == nil {
+		return false
+	}
+	older, newer := false, false
+	for _, rel := range page {
+		v, ok := ParseVersion(rel.Tag)
+		if !ok {
+			continue
+		}
+		switch v.Compare(*r.Since) {
+		case 0:
+			return true
+		case 1:
+			newer = true
+		default:
+			older = true
+		}
+	}
+	return older && !newer
+}
+
+// FilterReleases keeps the releases whose tag is within r.
+func FilterReleases(releases []forge.Release, r VersionRange) []forge.Release {
+	var out []forge.Release
+	for _, rel := range releases {
+		if r.Contains(rel.Tag) {
+			out = append(out, rel)
+		}
+	}
+	return out
+}
+
+// ChangelogSection is the entry for one version of a changelog.
+type ChangelogSection struct {
+	// Heading is the section's heading line, e.g. "## [1.4.0] - 2024-05-01".
+	Heading string
+	Body    string
+}
+
+// ParseChangelog splits a Markdown changelog into sections. The first
+// heading that names a version sets the level of version headings, and every
+// heading at that level starts a section, "## Unreleased" included. Deeper
+// headings are part of the body; text under shallower headings, such as the
+// title and introduction, is dropped.
+func ParseChangelog(content string) []ChangelogSection {
+	var lines []string
+	for line := range strings.Lines(content) {
+		lines = append(lines, strings.TrimRight(line, "\r\n"))
+	}
+	levels := headingLevels(lines)
+
+	level := 0
+	for i, n := range levels {
+		if _, ok := ParseVersion(lines[i]); n > 0 && ok {
+			level = n
+			break
+		}
+	}
+	if level == 0 {
+		return nil
+	}
+
+	var sections []ChangelogSection
+	var body []string
+	inSection := false
+	flush := func() {
+		if inSection {
+			sections[len(sections)-1].Body = strings.Trim(strings.Join(body, "\n"), "\n")
+		}
+		body = nil
+	}
+	for i, line := range lines {
+		switch n := levels[i]; {
+		case n == level:
+			flush()
+			sections = append(sections, ChangelogSection{Heading: line})
+			inSection = true
+		case n > 0 && n < level:
+			flush()
+			inSection = false
+		case inSection:
+			body = append(body, line)
+		}
+	}
+	flush()
+	return sections
+}
+
+// headingLevels returns the heading level of each line, or 0 for lines that
+// are not headings, including lines inside fenced code blocks.
+func headingLevels(lines []string) []int {
+	levels := make([]int, len(lines))
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+			continue
+		}
+		if !inFence {
+			levels[i] = headingLevel(line)
+		}
+	}
+	return levels
+}
+
+// headingLevel returns the level of an ATX heading, or 0 for other lines.
+func headingLevel(line string) int {
+	n := len(line) - len(strings.TrimLeft(line, "#"))
+	if n == 0 || n > 6 || (len(line) > n && line[n] != ' ') {
+		return 0
+	}
+	return n
+}
+
+// FilterChangelog keeps the sections whose heading is within r.
+func FilterChangelog(sections []ChangelogSection, r VersionRange) []ChangelogSection {
+	var out []ChangelogSection
+	for _, s := range sections {
+		if r.Contains(s.Heading) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// FormatReleases prints each release as a heading with its tag, name and
+// publication date, followed by its notes.
+func FormatReleases(releases []forge.Release) string {
+	var sb strings.Builder
+	for i, r := range releases {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		heading := "## " + r.Tag
+		if r.Name != "" && r.Name != r.Tag {
+			heading += ": " + r.Name
+		}
+		if len(r.Published) >= 10 {
+			heading += " (" + r.Published[:10] + ")"
+		}
+		if r.Prerelease {
+			heading += " [pre-release]"
+		}
+		sb.WriteString(heading + "\n")
+
+		body := strings.TrimSpace(strings.ReplaceAll(r.Body, "\r\n", "\n"))
+		if body == "" {
+			body = "(no release notes)"
+		}
+		sb.WriteString("\n" + body + "\n")
+	}
+	return sb.String()
+}
+
+// FormatChangelog prints the sections of a changelog under a note naming
+// the file they came from.
+func FormatChangelog(file string, sections []ChangelogSection) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("No releases; showing %s\n", file))
+	for _, s := range sections {
+		sb.WriteString("\n" + s.Heading + "\n")
+		if s.Body != "" {
+			sb.WriteString("\n" + s.Body + "\n")
+		}
+	}
+	return sb.String()
+}
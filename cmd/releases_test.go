@@ -0,0 +1,178 @@
+// ABOUTME: Tests for the releases command logic.
+// ABOUTME: Verifies version ordering, range filtering, changelog splitting and formatting.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/bartriepe/my-docs/forge"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Version
+	}{
+		{"v1.4.0", Version{Major: 1, Minor: 4}},
+		{"component/v1.4.2-rc.1", Version{Major: 1, Minor: 4, Patch: 2, Pre: "rc.1"}},
+		{"## [0.10.3] - 2024-05-01", Version{Minor: 10, Patch: 3}},
+		{"release-2.1", Version{Major: 2, Minor: 1}},
+	}
+	for _, tt := range tests {
+		got, ok := ParseVersion(tt.in)
+		if !ok || got != tt.want {
+			t.Errorf("ParseVersion(%q) = %+v, %v, want %+v", tt.in, got, ok, tt.want)
+		}
+	}
+	if _, ok := ParseVersion("## Unreleased"); ok {
+		t.Error("ParseVersion(\"## Unreleased\") ok = true, want false")
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.2.0", "1.10.0", "2.0.0"}
+	for i := 1; i < len(ordered); i++ {
+		a, _ := ParseVersion(ordered[i-1])
+		b, _ := ParseVersion(ordered[i])
+		if a.Compare(b) != -1 || b.Compare(a) != 1 {
+			t.Errorf("Compare(%s, %s) = %d, want -1", ordered[i-1], ordered[i], a.Compare(b))
+		}
+	}
+	a, _ := ParseVersion("v1.2")
+	b, _ := ParseVersion("1.2.0")
+	if a.Compare(b) != 0 {
+		t.Errorf("Compare(v1.2, 1.2.0) = %d, want 0", a.Compare(b))
+	}
+}
+
+func TestVersionRange(t *testing.T) {
+	r, err := ParseVersionRange("v1.2.0", "1.5")
+	if err != nil {
+		t.Fatalf("ParseVersionRange() error = %v", err)
+	}
+	for in, want := range map[string]bool{
+		"v1.1.9":     false,
+		"v1.2.0":     true,
+		"v1.3.0-rc1": true,
+		"v1.5.0":     true,
+		"v1.5.1":     false,
+		"nightly":    false,
+	} {
+		if got := r.Contains(in); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", in, got, want)
+		}
+	}
+	if !(VersionRange{}).Contains("nightly") {
+		t.Error("open range Contains(\"nightly\") = false, want true")
+	}
+	if _, err := ParseVersionRange("latest", ""); err == nil {
+		t.Error("ParseVersionRange(\"latest\") error = nil, want error")
+	}
+}
+
+func TestVersionRangePassed(t *testing.T) {
+	page := func(tags ...string) []forge.Release {
+		var releases []forge.Release
+		for _, tag := range tags {
+			releases = append(releases, forge.Release{Tag: tag})
+		}
+		return releases
+	}
+	r, _ := ParseVersionRange("v2.0.0", "")
+	tests := []struct {
+		name string
+		page []forge.Release
+		want bool
+	}{
+		{"newer", page("v2.2.0", "v2.1.0"), false},
+		{"backport among newer", page("v2.1.0", "v1.9.9", "v2.0.1"), false},
+		{"since itself", page("v2.1.0", "v2.0.0", "v1.9.9"), true},
+		{"only older", page("v1.9.9", "nightly", "v1.9.8"), true},
+		{"no versions", page("nightly"), false},
+	}
+	for _, tt := range tests {
+		if got := r.Passed(tt.page); got != tt.want {
+			t.Errorf("Passed(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if (VersionRange{}).Passed(page("v0.1.0")) {
+		t.Error("open range Passed() = true, want false")
+	}
+}
+
+const testChangelog = `# Changelog
+
+All notable changes to this project are documented here.
+
+## [Unreleased]
+
+- Work in progress
+
+## [1.5.0] - 2024-05-01
+
+### Added
+
+- A new exporter
+
+` + "```" + `
+## not a heading
+` + "```" + `
+
+## [1.4.0] - 2024-03-01
+
+- Fixed a crash
+
+## [1.3.0] - 2024-01-01
+`
+
+func TestParseChangelog(t *testing.T) {
+	sections := ParseChangelog(testChangelog)
+
+	var headings []string
+	for _, s := range sections {
+		headings = append(headings, s.Heading)
+	}
+	want := []string{"## [Unreleased]", "## [1.5.0] - 2024-05-01", "## [1.4.0] - 2024-03-01", "## [1.3.0] - 2024-01-01"}
+	if len(headings) != len(want) {
+		t.Fatalf("ParseChangelog() headings = %q, want %q", headings, want)
+	}
+	for i := range want {
+		if headings[i] != want[i] {
+			t.Errorf("heading %d = %q, want %q", i, headings[i], want[i])
+		}
+	}
+
+	wantBody := "### Added\n\n- A new exporter\n\n```\n## not a heading\n```"
+	if sections[1].Body != wantBody {
+		t.Errorf("1.5.0 body = %q, want %q", sections[1].Body, wantBody)
+	}
+	if sections[3].Body != "" {
+		t.Errorf("1.3.0 body = %q, want empty", sections[3].Body)
+	}
+
+	r, _ := ParseVersionRange("1.4.0", "")
+	if got := FilterChangelog(sections, r); len(got) != 2 || got[1].Heading != want[2] {
+		t.Errorf("FilterChangelog() = %+v, want 1.5.0 and 1.4.0", got)
+	}
+}
+
+func TestFormatReleases(t *testing.T) {
+	releases := []forge.Release{
+		{Tag: "v1.5.0", Name: "Shiny", Body: "- New exporter\r\n", Published: "2024-05-01T10:00:00Z"},
+		{Tag: "v1.5.0-rc.1", Name: "v1.5.0-rc.1", Prerelease: true},
+	}
+	want := "## v1.5.0: Shiny (2024-05-01)\n\n- New exporter\n" +
+		"\n## v1.5.0-rc.1 [pre-release]\n\n(no release notes)\n"
+	if got := FormatReleases(releases); got != want {
+		t.Errorf("FormatReleases() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatChangelog(t *testing.T) {
+	sections := []ChangelogSection{{Heading: "## 1.5.0", Body: "- New exporter"}, {Heading: "## 1.4.0"}}
+	want := "No releases; showing CHANGELOG.md\n\n## 1.5.0\n\n- New exporter\n\n## 1.4.0\n"
+	if got := FormatChangelog("CHANGELOG.md", sections); got != want {
+		t.Errorf("FormatChangelog() = %q, want %q", got, want)
+	}
+}
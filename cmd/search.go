@@ -0,0 +1,371 @@
+// ABOUTME: Logic for the search command.
+// ABOUTME: Collects match lines from paged grep.app hits and formats result pages.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bartriepe/my-docs/grepapp"
+	"github.com/bartriepe/my-docs/repospec"
+)
+
+// HitSource yields grep.app hits in batches, returning an empty batch once
+// there are no more.
+type HitSource interface {
+	Next() ([]grepapp.Hit, error)
+}
+
+// MatchLine is a single line of a search result.
+type MatchLine struct {
+	Repo    string
+	Branch  string
+	Path    string
+	Line    int
+	Text    string
+	Matched bool
+	// Ranges are the highlighted byte ranges of Text.
+	Ranges []grepapp.Range
+	// AlsoIn lists other repos holding an identical copy of the hit.
+	AlsoIn []string
+}
+
+func HitLines(hit grepapp.Hit) []MatchLine {
+	var lines []MatchLine
+	for _, m := range hit.Matches() {
+		lines = append(lines, MatchLine{
+			Repo:    hit.Repo,
+			Branch:  hit.Branch,
+			Path:    hit.Path,
+			Line:    m.Line,
+			Text:    m.Text,
+			Matched: m.Matched,
+			Ranges:  m.Ranges,
+		})
+	}
+	return lines
+}
+
+// Collector gathers search results from a HitSource, reading only as many
+// batches as it needs to fill the requested window.
+type Collector struct {
+	Source HitSource
+	// MatchedOnly drops the context rows grep.app includes around matches.
+	MatchedOnly bool
+	// Exclude drops hits whose path matches. Excluded hits do not count
+	// towards the window, so filtering keeps reading further pages.
+	Exclude *GlobSet
+	// Dedupe collapses copies of the same hit found in other repos, such as
+	// forks and vendored copies, into one result.
+	Dedupe bool
+	// Rank returns a repo's match count from the repo facet. When duplicates
+	// are collapsed, the highest-ranked copy is the one shown.
+	Rank func(repo string) int
+	// Hits holds every hit read from Source so far that was kept.
+	Hits []grepapp.Hit
+
+	alsoIn [][]string     // per hit in Hits
+	lines  [][]MatchLine  // per hit in Hits
+	groups map[string]int // fingerprint to index in Hits
+}
+
+// next reads one batch from Source and records the hits that are neither
+// excluded nor duplicates. It returns false once Source is exhausted.
+func (c *Collector) next() (bool, error) {
+	hits, err := c.Source.Next()
+	if err != nil {
+		return false, err
+	}
+	if len(hits) == 0 {
+		return false, nil
+	}
+	for _, hit := range hits {
+		if c.Exclude.Match(hit.Path) {
+			continue
+		}
+		if c.Dedupe && c.merge(hit) {
+			continue
+		}
+		c.Hits = append(c.Hits, hit)
+		c.alsoIn = append(c.alsoIn, nil)
+		c.lines = append(c.lines, c.hitLines(hit))
+	}
+	return true, nil
+}
+
+// Lines reads batches until it holds at least n lines or the source runs dry.
+func (c *Collector) Lines(n int) ([]MatchLine, error) {
+	var err error
+	for c.lineCount() < n {
+		var ok bool
+		if ok, err = c.next(); err != nil || !ok {
+			break
+		}
+	}
+
+	var lines []MatchLine
+	for i, hitLines := range c.lines {
+		for _, l := range hitLines {
+			l.AlsoIn = c.alsoIn[i]
+			lines = append(lines, l)
+		}
+	}
+	return lines, err
+}
+
+func (c *Collector) lineCount() int {
+	n := 0
+	for _, hitLines := range c.lines {
+		n += len(hitLines)
+	}
+	return n
+}
+
+// FileMatch is a file with at least one match and its match count.
+type FileMatch struct {
+	Repo   string
+	Path   string
+	Count  string
+	AlsoIn []string
+}
+
+// Files reads batches until it holds at least n distinct files or the source
+// runs dry.
+func (c *Collector) Files(n int) ([]FileMatch, error) {
+	var files []FileMatch
+	var err error
+	for {
+		files = c.files()
+		if len(files) >= n {
+			break
+		}
+		var ok bool
+		if ok, err = c.next(); err != nil || !ok {
+			break
+		}
+	}
+	return files, err
+}
+
+func (c *Collector) files() []FileMatch {
+	var files []FileMatch
+	seen := make(map[string]bool)
+	for i, hit := range c.Hits {
+		key := hit.Repo + "\x00" + hit.Path
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		files = append(files, FileMatch{Repo: hit.Repo, Path: hit.Path, Count: hitMatchCount(hit), AlsoIn: c.alsoIn[i]})
+	}
+	return files
+}
+
+// hitMatchCount prefers grep.app's own count, which covers matches beyond the
+// snippet, and falls back to counting highlighted snippet rows.
+func hitMatchCount(hit grepapp.Hit) string {
+	if hit.TotalMatches != "" {
+		return hit.TotalMatches
+	}
+	n := 0
+	for _, m := range hit.Matches() {
+		if m.Matched {
+			n++
+		}
+	}
+	return strconv.Itoa(n)
+}
+
+func (c *Collector) hitLines(hit grepapp.Hit) []MatchLine {
+	lines := HitLines(hit)
+	if !c.MatchedOnly {
+		return lines
+	}
+	var matched []MatchLine
+	for _, l := range lines {
+		if l.Matched {
+			matched = append(matched, l)
+		}
+	}
+	return matched
+}
+
+// ParseRepoList splits a comma-separated repo argument into explicit repo
+// specs and the owners of "owner/*" wildcards.
+func ParseRepoList(arg string) (specs []repospec.Spec, orgs []string, err error) {
+	for _, entry := range strings.Split(arg, ",") {
+		entry = strings.TrimSpace(entry)
+		if owner, ok := strings.CutSuffix(entry, "/*"); ok && owner != "" && !strings.ContainsAny(owner, "/@") {
+			orgs = append(orgs, owner)
+			continue
+		}
+		spec, err := repospec.Parse(entry)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid repo format %q: must be [host/]owner/repo[@ref][//subdir] or owner/*", entry)
+		}
+		specs = append(specs, spec)
+	}
+	return specs, orgs, nil
+}
+
+// FormatOptions controls how search results are printed.
+type FormatOptions struct {
+	// ShowRepo prefixes paths with their repo, for results from several repos.
+	ShowRepo bool
+	// Color highlights matched text with ANSI escapes.
+	Color bool
+	// Column adds the 1-based byte column of the first match on each line.
+	Column bool
+	// Specs holds the searched repo specs by lower-cased repo name. Paths in
+	// a repo whose spec has a subdirectory are shown relative to it.
+	Specs map[string]repospec.Spec
+}
+
+// location is how results name a file: "path", or "repo:path" when results
+// come from more than one repo.
+func (o FormatOptions) location(repo, path string) string {
+	if spec, ok := o.Specs[strings.ToLower(repo)]; ok {
+		path = spec.Rel(path)
+	}
+	if o.ShowRepo && repo != "" {
+		return repo + ":" + path
+	}
+	return path
+}
+
+// text renders a line's text, highlighting its ranges when color is on.
+func (o FormatOptions) text(text string, ranges []grepapp.Range) string {
+	if !o.Color {
+		return text
+	}
+	return Highlight(text, ranges)
+}
+
+// lineNumber renders "N", or "N:C" when columns are on and the line matched.
+func (o FormatOptions) lineNumber(line int, ranges []grepapp.Range) string {
+	if o.Column && len(ranges) > 0 {
+		return fmt.Sprintf("%d:%d", line, ranges[0].Start+1)
+	}
+	return strconv.Itoa(line)
+}
+
+const (
+	highlightStart = "\x1b[1;31m"
+	highlightEnd   = "\x1b[0m"
+)
+
+// Highlight wraps each range of text in ANSI bold red. Ranges must be sorted
+// and non-overlapping; out-of-bounds ranges are ignored.
+func Highlight(text string, ranges []grepapp.Range) string {
+	var sb strings.Builder
+	pos := 0
+	for _, r := range ranges {
+		if r.Start < pos || r.End > len(text) || r.Start >= r.End {
+			continue
+		}
+		sb.WriteString(text[pos:r.Start])
+		sb.WriteString(highlightStart + text[r.Start:r.End] + highlightEnd)
+		pos = r.End
+	}
+	sb.WriteString(text[pos:])
+	return sb.String()
+}
+
+// lastOfFile reports whether lines[i] is the last of its file's run, where a
+// note about duplicates belongs.
+func lastOfFile(lines []MatchLine, i int) bool {
+	return i+1 == len(lines) || lines[i+1].Repo != lines[i].Repo || lines[i+1].Path != lines[i].Path
+}
+
+// FormatLines prints one "path:line: text" row per match.
+func FormatLines(lines []MatchLine, opts FormatOptions) string {
+	var sb strings.Builder
+	for i, l := range lines {
+		sb.WriteString(fmt.Sprintf("%s:%s: %s\n", opts.location(l.Repo, l.Path), opts.lineNumber(l.Line, l.Ranges), opts.text(l.Text, l.Ranges)))
+		if lastOfFile(lines, i) {
+			sb.WriteString(formatAlsoIn(l.AlsoIn))
+		}
+	}
+	return sb.String()
+}
+
+// FormatFiles lists one matching file per line, like grep -l.
+func FormatFiles(files []FileMatch, opts FormatOptions) string {
+	var sb strings.Builder
+	for _, f := range files {
+		sb.WriteString(opts.location(f.Repo, f.Path) + "\n")
+		sb.WriteString(formatAlsoIn(f.AlsoIn))
+	}
+	return sb.String()
+}
+
+// FormatCounts lists each matching file with its match count, like grep -c.
+func FormatCounts(files []FileMatch, opts FormatOptions) string {
+	var sb strings.Builder
+	for _, f := range files {
+		sb.WriteString(fmt.Sprintf("%s:%s\n", opts.location(f.Repo, f.Path), f.Count))
+		sb.WriteString(formatAlsoIn(f.AlsoIn))
+	}
+	return sb.String()
+}
+
+// FormatGrouped prints each file once as a heading with its lines indented
+// beneath it.
+func FormatGrouped(lines []MatchLine, opts FormatOptions) string {
+	var sb strings.Builder
+	prev := ""
+	for i, l := range lines {
+		key := l.Repo + "\x00" + l.Path
+		if key != prev {
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			sb.WriteString(opts.location(l.Repo, l.Path) + "\n")
+			prev = key
+		}
+		sb.WriteString(fmt.Sprintf("  %s: %s\n", opts.lineNumber(l.Line, l.Ranges), opts.text(l.Text, l.Ranges)))
+		if lastOfFile(lines, i) {
+			sb.WriteString(formatAlsoIn(l.AlsoIn))
+		}
+	}
+	return sb.String()
+}
+
+// FormatPageFooter describes the page just shown and how to fetch the next one.
+// end is the index one past the last result shown.
+func FormatPageFooter(offset, end, totalFiles int) string {
+	return fmt.Sprintf("\n... showing results %d-%d, %d matching files in total (use --offset %d to see next page)\n",
+		offset+1, end, totalFiles, end)
+}
+
+// FormatFacets summarises where a query matched: the total, the query time and
+// the repo, language and top-level path breakdowns grep.app reports.
+func FormatFacets(resp *grepapp.Response) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d matching files (query took %dms)\n", resp.Hits.Total, resp.Time))
+
+	groups := []struct {
+		title string
+		group grepapp.FacetGroup
+	}{
+		{"Repositories", resp.Facets.Repo},
+		{"Languages", resp.Facets.Lang},
+		{"Paths", resp.Facets.Path},
+	}
+	for _, g := range groups {
+		if len(g.group.Buckets) == 0 {
+			continue
+		}
+		width := 0
+		for _, b := range g.group.Buckets {
+			width = max(width, len(b.Val))
+		}
+		sb.WriteString(fmt.Sprintf("\n%s:\n", g.title))
+		for _, b := range g.group.Buckets {
+			sb.WriteString(fmt.Sprintf("  %-*s  %d\n", width, b.Val, b.Count))
+		}
+	}
+	return sb.String()
+}
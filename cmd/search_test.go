@@ -0,0 +1,303 @@
+// ABOUTME: Tests for the search command logic.
+// ABOUTME: Verifies line collection across hit batches and page footers.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bartriepe/my-docs/grepapp"
+	"github.com/bartriepe/my-docs/repospec"
+)
+
+// fakeSource returns one prepared batch per call to Next.
+type fakeSource struct {
+	batches [][]grepapp.Hit
+	calls   int
+}
+
+func (f *fakeSource) Next() ([]grepapp.Hit, error) {
+	if f.calls >= len(f.batches) {
+		f.calls++
+		return nil, nil
+	}
+	batch := f.batches[f.calls]
+	f.calls++
+	return batch, nil
+}
+
+func snippetHit(repo, path string, lines ...string) grepapp.Hit {
+	var sb strings.Builder
+	sb.WriteString(`<table class="highlight-table">`)
+	for i, text := range lines {
+		fmt.Fprintf(&sb, `<tr data-line="%d"><td><div class="lineno">%d</div></td><td><div class="highlight"><pre>%s</pre></div></td></tr>`, i+1, i+1, text)
+	}
+	sb.WriteString(`</table>`)
+	return grepapp.Hit{Repo: repo, Path: path, Content: grepapp.Content{Snippet: sb.String()}}
+}
+
+func TestCollectorLines_StopsWhenEnough(t *testing.T) {
+	src := &fakeSource{batches: [][]grepapp.Hit{
+		{snippetHit("a/b", "one.go", "x", "y")},
+		{snippetHit("a/b", "two.go", "z")},
+		{snippetHit("a/b", "three.go", "w")},
+	}}
+	c := &Collector{Source: src}
+
+	lines, err := c.Lines(3)
+	if err != nil {
+		t.Fatalf("Lines() error = %v", err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("Lines() returned %d lines, want 3", len(lines))
+	}
+	if src.calls != 2 {
+		t.Errorf("Lines() called Next %d times, want 2", src.calls)
+	}
+	if lines[2].Path != "two.go" || lines[2].Text != "z" {
+		t.Errorf("Lines()[2] = %+v, want two.go:1: z", lines[2])
+	}
+	if len(c.Hits) != 2 {
+		t.Errorf("Collector.Hits has %d hits, want 2", len(c.Hits))
+	}
+}
+
+func TestCollectorLines_SourceExhausted(t *testing.T) {
+	src := &fakeSource{batches: [][]grepapp.Hit{
+		{snippetHit("a/b", "one.go", "x")},
+	}}
+	c := &Collector{Source: src}
+
+	lines, err := c.Lines(10)
+	if err != nil {
+		t.Fatalf("Lines() error = %v", err)
+	}
+	if len(lines) != 1 {
+		t.Errorf("Lines() returned %d lines, want 1", len(lines))
+	}
+}
+
+func TestCollectorLines_MatchedOnly(t *testing.T) {
+	src := &fakeSource{batches: [][]grepapp.Hit{
+		{snippetHit("a/b", "one.go", "before", "<mark>hit</mark>", "after")},
+		{snippetHit("a/b", "two.go", "<mark>second</mark>")},
+	}}
+	c := &Collector{Source: src, MatchedOnly: true}
+
+	lines, err := c.Lines(2)
+	if err != nil {
+		t.Fatalf("Lines() error = %v", err)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("Lines() returned %d lines, want 2", len(lines))
+	}
+	if lines[0].Line != 2 || lines[0].Text != "hit" {
+		t.Errorf("Lines()[0] = %+v, want one.go:2: hit", lines[0])
+	}
+	if lines[1].Path != "two.go" {
+		t.Errorf("Lines()[1].Path = %q, want two.go", lines[1].Path)
+	}
+}
+
+func TestFormatPageFooter(t *testing.T) {
+	got := FormatPageFooter(15, 30, 2315)
+	if !strings.Contains(got, "results 16-30") {
+		t.Errorf("FormatPageFooter() = %q, want results 16-30", got)
+	}
+	if !strings.Contains(got, "2315 matching files") {
+		t.Errorf("FormatPageFooter() = %q, want total of 2315 files", got)
+	}
+	if !strings.Contains(got, "--offset 30") {
+		t.Errorf("FormatPageFooter() = %q, want --offset 30 hint", got)
+	}
+}
+
+func TestFormatFacets(t *testing.T) {
+	resp := &grepapp.Response{
+		Time: 422,
+		Facets: grepapp.Facets{
+			Repo: grepapp.FacetGroup{Buckets: []grepapp.Bucket{{Val: "grafana/alloy", Count: 1423}, {Val: "alloy-rs/alloy", Count: 892}}},
+			Lang: grepapp.FacetGroup{Buckets: []grepapp.Bucket{{Val: "Markdown", Count: 500}}},
+		},
+		Hits: grepapp.Hits{Total: 2315},
+	}
+
+	output := FormatFacets(resp)
+
+	for _, want := range []string{
+		"2315 matching files (query took 422ms)",
+		"Repositories:\n  grafana/alloy   1423\n  alloy-rs/alloy  892\n",
+		"Languages:\n  Markdown  500\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("FormatFacets() = %q, want it to contain %q", output, want)
+		}
+	}
+	if strings.Contains(output, "Paths:") {
+		t.Error("FormatFacets() should omit empty facet groups")
+	}
+}
+
+func TestCollectorFiles(t *testing.T) {
+	counted := snippetHit("a/b", "one.go", "<mark>x</mark>")
+	counted.TotalMatches = "100+"
+	src := &fakeSource{batches: [][]grepapp.Hit{
+		{counted, snippetHit("a/b", "two.go", "<mark>y</mark>", "ctx", "<mark>z</mark>")},
+		{snippetHit("a/b", "one.go", "<mark>x</mark>"), snippetHit("a/b", "three.go", "<mark>w</mark>")},
+		{snippetHit("a/b", "four.go", "<mark>v</mark>")},
+	}}
+	c := &Collector{Source: src}
+
+	files, err := c.Files(3)
+	if err != nil {
+		t.Fatalf("Files() error = %v", err)
+	}
+	want := []FileMatch{
+		{Repo: "a/b", Path: "one.go", Count: "100+"},
+		{Repo: "a/b", Path: "two.go", Count: "2"},
+		{Repo: "a/b", Path: "three.go", Count: "1"},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("Files() returned %d files, want %d", len(files), len(want))
+	}
+	for i := range want {
+		if files[i].Repo != want[i].Repo || files[i].Path != want[i].Path || files[i].Count != want[i].Count {
+			t.Errorf("Files()[%d] = %+v, want %+v", i, files[i], want[i])
+		}
+	}
+	if src.calls != 2 {
+		t.Errorf("Files() called Next %d times, want 2", src.calls)
+	}
+}
+
+func TestFormatFilesAndCounts(t *testing.T) {
+	files := []FileMatch{{Path: "one.go", Count: "3"}, {Path: "docs/two.md", Count: "100+"}}
+
+	if got, want := FormatFiles(files, FormatOptions{}), "one.go\ndocs/two.md\n"; got != want {
+		t.Errorf("FormatFiles() = %q, want %q", got, want)
+	}
+	if got, want := FormatCounts(files, FormatOptions{}), "one.go:3\ndocs/two.md:100+\n"; got != want {
+		t.Errorf("FormatCounts() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatGrouped(t *testing.T) {
+	lines := []MatchLine{
+		{Path: "one.go", Line: 3, Text: "a"},
+		{Path: "one.go", Line: 7, Text: "b"},
+		{Path: "two.go", Line: 1, Text: "c"},
+	}
+
+	got := FormatGrouped(lines, FormatOptions{})
+	want := "one.go\n  3: a\n  7: b\n\ntwo.go\n  1: c\n"
+	if got != want {
+		t.Errorf("FormatGrouped() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatLines_ShowRepo(t *testing.T) {
+	lines := []MatchLine{
+		{Repo: "grafana/alloy", Path: "main.go", Line: 3, Text: "a"},
+		{Repo: "grafana/loki", Path: "pkg/x.go", Line: 9, Text: "b"},
+	}
+
+	if got, want := FormatLines(lines, FormatOptions{}), "main.go:3: a\npkg/x.go:9: b\n"; got != want {
+		t.Errorf("FormatLines() without repos = %q, want %q", got, want)
+	}
+	if got, want := FormatLines(lines, FormatOptions{ShowRepo: true}), "grafana/alloy:main.go:3: a\ngrafana/loki:pkg/x.go:9: b\n"; got != want {
+		t.Errorf("FormatLines() with repos = %q, want %q", got, want)
+	}
+}
+
+func TestFormatLines_Subdir(t *testing.T) {
+	lines := []MatchLine{
+		{Repo: "grafana/alloy", Path: "docs/sources/intro.md", Line: 3, Text: "a"},
+		{Repo: "grafana/alloy", Path: "README.md", Line: 1, Text: "b"},
+	}
+	opts := FormatOptions{Specs: map[string]repospec.Spec{
+		"grafana/alloy": {Repo: "grafana/alloy", Subdir: "docs/sources"},
+	}}
+
+	if got, want := FormatLines(lines, opts), "intro.md:3: a\nREADME.md:1: b\n"; got != want {
+		t.Errorf("FormatLines() = %q, want %q", got, want)
+	}
+}
+
+func TestParseRepoList(t *testing.T) {
+	specs, orgs, err := ParseRepoList("grafana/alloy, grafana/loki//docs,prometheus/*")
+	if err != nil {
+		t.Fatalf("ParseRepoList() error = %v", err)
+	}
+	want := []repospec.Spec{{Repo: "grafana/alloy"}, {Repo: "grafana/loki", Subdir: "docs"}}
+	if len(specs) != 2 || specs[0] != want[0] || specs[1] != want[1] {
+		t.Errorf("ParseRepoList() specs = %+v, want %+v", specs, want)
+	}
+	if len(orgs) != 1 || orgs[0] != "prometheus" {
+		t.Errorf("ParseRepoList() orgs = %v, want [prometheus]", orgs)
+	}
+
+	for _, bad := range []string{"grafana", "grafana/", "/alloy", "a/b/c", "grafana/alloy,", "grafana/*@v1"} {
+		if _, _, err := ParseRepoList(bad); err == nil {
+			t.Errorf("ParseRepoList(%q) error = nil, want error", bad)
+		}
+	}
+}
+
+func TestCollectorLines_ExcludeKeepsPaging(t *testing.T) {
+	exclude, err := NewGlobSet(VendorGlobs)
+	if err != nil {
+		t.Fatalf("NewGlobSet() error = %v", err)
+	}
+	src := &fakeSource{batches: [][]grepapp.Hit{
+		{snippetHit("a/b", "vendor/x/one.go", "v1"), snippetHit("a/b", "vendor/x/two.go", "v2")},
+		{snippetHit("a/b", "node_modules/y/index.js", "v3")},
+		{snippetHit("a/b", "pkg/real.go", "real")},
+	}}
+	c := &Collector{Source: src, Exclude: exclude}
+
+	lines, err := c.Lines(1)
+	if err != nil {
+		t.Fatalf("Lines() error = %v", err)
+	}
+	if len(lines) != 1 || lines[0].Path != "pkg/real.go" {
+		t.Errorf("Lines() = %+v, want only pkg/real.go", lines)
+	}
+	if src.calls != 3 {
+		t.Errorf("Lines() called Next %d times, want 3", src.calls)
+	}
+	if len(c.Hits) != 1 {
+		t.Errorf("Collector.Hits has %d hits, want 1", len(c.Hits))
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	ranges := []grepapp.Range{{Start: 4, End: 8}, {Start: 13, End: 17}}
+	got := Highlight("the alloc of alloc", ranges)
+	want := "the \x1b[1;31mallo\x1b[0mc of \x1b[1;31mallo\x1b[0mc"
+	if got != want {
+		t.Errorf("Highlight() = %q, want %q", got, want)
+	}
+
+	if got := Highlight("short", []grepapp.Range{{Start: 2, End: 99}}); got != "short" {
+		t.Errorf("Highlight() with out-of-bounds range = %q, want unchanged text", got)
+	}
+}
+
+func TestFormatLines_ColorAndColumn(t *testing.T) {
+	lines := []MatchLine{
+		{Path: "main.go", Line: 3, Text: "func Start()", Matched: true, Ranges: []grepapp.Range{{Start: 5, End: 10}}},
+		{Path: "main.go", Line: 4, Text: "context"},
+	}
+
+	got := FormatLines(lines, FormatOptions{Column: true})
+	if want := "main.go:3:6: func Start()\nmain.go:4: context\n"; got != want {
+		t.Errorf("FormatLines() with columns = %q, want %q", got, want)
+	}
+
+	got = FormatLines(lines, FormatOptions{Color: true})
+	if want := "main.go:3: func \x1b[1;31mStart\x1b[0m()\n"; !strings.HasPrefix(got, want) {
+		t.Errorf("FormatLines() with color = %q, want prefix %q", got, want)
+	}
+}
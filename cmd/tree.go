@@ -0,0 +1,188 @@
+// ABOUTME: Logic for the ls and tree commands.
+// ABOUTME: Turns a flat repo tree listing into sized directory listings and indented trees.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bartriepe/my-docs/forge"
+)
+
+// Subtree returns the entries under dir with paths made relative to it. ok is
+// false when dir does not exist in entries. An empty dir is the repo root.
+func Subtree(entries []forge.TreeEntry, dir string) (sub []forge.TreeEntry, ok bool) {
+	dir = strings.Trim(dir, "/")
+	if dir == "" {
+		return entries, true
+	}
+	for _, e := range entries {
+		if e.Path == dir && e.Type == "tree" {
+			ok = true
+			continue
+		}
+		if rel, found := strings.CutPrefix(e.Path, dir+"/"); found {
+			e.Path = rel
+			sub = append(sub, e)
+			ok = true
+		}
+	}
+	return sub, ok
+}
+
+// treeNode is a file or directory in a tree built from a flat listing. The
+// size of a directory is the total size of the files beneath it, or -1 when
+// any of them has an unknown size.
+type treeNode struct {
+	name      string
+	size      int64
+	dir       bool
+	submodule bool
+	children  map[string]*treeNode
+}
+
+// buildTree nests entries into directories. When include is non-empty, only
+// the files it matches are kept, along with the directories leading to them.
+func buildTree(entries []forge.TreeEntry, include *GlobSet) *treeNode {
+	root := &treeNode{dir: true, children: make(map[string]*treeNode)}
+	filter := include != nil && !include.Empty()
+
+	for _, e := range entries {
+		if filter && (e.Type != "blob" || !include.Match(e.Path)) {
+			continue
+		}
+
+		parts := strings.Split(e.Path, "/")
+		node := root
+		for i, part := range parts {
+			child, ok := node.children[part]
+			if !ok {
+				child = &treeNode{name: part, children: make(map[string]*treeNode)}
+				node.children[part] = child
+			}
+			if i < len(parts)-1 {
+				child.dir = true
+			}
+			node = child
+		}
+		switch e.Type {
+		case "tree":
+			node.dir = true
+		case "commit":
+			node.submodule = true
+		case "blob":
+			node.size = e.Size
+		}
+	}
+
+	root.sumSizes()
+	return root
+}
+
+func (n *treeNode) sumSizes() int64 {
+	if !n.dir {
+		return n.size
+	}
+	n.size = 0
+	unknown := false
+	for _, c := range n.children {
+		size := c.sumSizes()
+		if size < 0 {
+			unknown = true
+		}
+		n.size += size
+	}
+	if unknown {
+		n.size = -1
+	}
+	return n.size
+}
+
+// sorted returns the children of n, directories first, each group by name.
+func (n *treeNode) sorted() []*treeNode {
+	children := make([]*treeNode, 0, len(n.children))
+	for _, c := range n.children {
+		children = append(children, c)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		if children[i].dir != children[j].dir {
+			return children[i].dir
+		}
+		return children[i].name < children[j].name
+	})
+	return children
+}
+
+func (n *treeNode) label() string {
+	switch {
+	case n.dir:
+		return n.name + "/"
+	case n.submodule:
+		return n.name + "@"
+	}
+	return n.name
+}
+
+func (n *treeNode) sizeText() string {
+	if n.submodule {
+		return "submodule"
+	}
+	return FormatSize(n.size)
+}
+
+// FormatLs lists the top level of entries, one name per line with its size.
+// Directories end in "/" and show the total size of their files.
+func FormatLs(entries []forge.TreeEntry) string {
+	children := buildTree(entries, nil).sorted()
+
+	width := 0
+	for _, c := range children {
+		width = max(width, len(c.label()))
+	}
+
+	var sb strings.Builder
+	for _, c := range children {
+		sb.WriteString(fmt.Sprintf("%-*s  %s\n", width, c.label(), c.sizeText()))
+	}
+	return sb.String()
+}
+
+// FormatTree prints entries as an indented tree, with sizes in parentheses.
+// depth limits how many levels are shown, where 0 means all of them. When
+// include is non-empty, only matching files and their directories are shown.
+func FormatTree(entries []forge.TreeEntry, depth int, include *GlobSet) string {
+	var sb strings.Builder
+	var walk func(n *treeNode, level int)
+	walk = func(n *treeNode, level int) {
+		for _, c := range n.sorted() {
+			sb.WriteString(fmt.Sprintf("%s%s (%s)\n", strings.Repeat("  ", level), c.label(), c.sizeText()))
+			if c.dir && (depth == 0 || level+1 < depth) {
+				walk(c, level+1)
+			}
+		}
+	}
+	walk(buildTree(entries, include), 0)
+	return sb.String()
+}
+
+// FormatSize renders a byte count the way ls -h does, to one decimal place.
+// A negative count is an unknown size and renders as "?".
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < 0 {
+		return "?"
+	}
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n)
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		value /= unit
+		if value < unit || suffix == "GB" {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+	}
+	return ""
+}
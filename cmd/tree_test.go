@@ -0,0 +1,121 @@
+// ABOUTME: Tests for the ls and tree command logic.
+// ABOUTME: Verifies subtree selection, directory sizes, depth limits and glob filtering.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/bartriepe/my-docs/forge"
+)
+
+var testTree = []forge.TreeEntry{
+	{Path: "README.md", Type: "blob", Size: 2048},
+	{Path: "docs", Type: "tree"},
+	{Path: "docs/intro.md", Type: "blob", Size: 100},
+	{Path: "docs/reference", Type: "tree"},
+	{Path: "docs/reference/api.md", Type: "blob", Size: 400},
+	{Path: "docs/reference/logo.png", Type: "blob", Size: 5000},
+	{Path: "go.mod", Type: "blob", Size: 12},
+	{Path: "third_party/lib", Type: "commit"},
+}
+
+func TestSubtree(t *testing.T) {
+	sub, ok := Subtree(testTree, "docs/")
+	if !ok {
+		t.Fatal("Subtree(docs/) ok = false, want true")
+	}
+	if len(sub) != 4 || sub[0].Path != "intro.md" || sub[2].Path != "reference/api.md" {
+		t.Errorf("Subtree(docs/) = %+v, want paths relative to docs", sub)
+	}
+
+	if _, ok := Subtree(testTree, "nope"); ok {
+		t.Error("Subtree(nope) ok = true, want false")
+	}
+	if _, ok := Subtree(testTree, "README.md"); ok {
+		t.Error("Subtree(README.md) ok = true, want false for a file")
+	}
+}
+
+func TestFormatLs(t *testing.T) {
+	want := "docs/         5.4 KB\n" +
+		"third_party/  0 B\n" +
+		"README.md     2.0 KB\n" +
+		"go.mod        12 B\n"
+	if got := FormatLs(testTree); got != want {
+		t.Errorf("FormatLs() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatLs_UnknownSizes(t *testing.T) {
+	entries := []forge.TreeEntry{
+		{Path: "docs", Type: "tree", Size: -1},
+		{Path: "docs/intro.md", Type: "blob", Size: -1},
+		{Path: "README.md", Type: "blob", Size: -1},
+	}
+	want := "docs/      ?\n" +
+		"README.md  ?\n"
+	if got := FormatLs(entries); got != want {
+		t.Errorf("FormatLs() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatTree(t *testing.T) {
+	tests := []struct {
+		name  string
+		depth int
+		globs []string
+		want  string
+	}{
+		{
+			name:  "depth",
+			depth: 2,
+			want: "docs/ (5.4 KB)\n" +
+				"  reference/ (5.3 KB)\n" +
+				"  intro.md (100 B)\n" +
+				"third_party/ (0 B)\n" +
+				"  lib@ (submodule)\n" +
+				"README.md (2.0 KB)\n" +
+				"go.mod (12 B)\n",
+		},
+		{
+			name:  "glob",
+			globs: []string{"*.md"},
+			want: "docs/ (500 B)\n" +
+				"  reference/ (400 B)\n" +
+				"    api.md (400 B)\n" +
+				"  intro.md (100 B)\n" +
+				"README.md (2.0 KB)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			include, err := NewGlobSet(tt.globs)
+			if err != nil {
+				t.Fatalf("NewGlobSet() error = %v", err)
+			}
+			if got := FormatTree(testTree, tt.depth, include); got != tt.want {
+				t.Errorf("FormatTree() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KB"},
+		{5 << 20, "5.0 MB"},
+		{-1, "?"},
+	}
+	for _, tt := range tests {
+		if got := FormatSize(tt.n); got != tt.want {
+			t.Errorf("FormatSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -1,4 +1,4 @@
-// ABOUTME: Manages the JSON config file for crate-to-repo mappings.
+// ABOUTME: Manages the JSON config file for crate-to-repo mappings and cached repo metadata.
 // ABOUTME: Handles loading, saving, and locating the config file.
 
 package config
@@ -7,10 +7,87 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
+// BranchTTL is how long a cached default branch is trusted before it is
+// looked up again.
+const BranchTTL = 7 * 24 * time.Hour
+
 type Config struct {
 	Crates map[string]string `json:"crates,omitempty"`
+	Search SearchDefaults    `json:"search,omitzero"`
+	// Branches caches each repo's default branch, keyed by lower-cased repo.
+	Branches map[string]BranchEntry `json:"branches,omitempty"`
+	// GitHubToken authenticates GitHub requests when neither GITHUB_TOKEN nor
+	// GH_TOKEN is set.
+	GitHubToken string `json:"github_token,omitempty"`
+	// Hosts configures git hosts other than GitHub, keyed by host name such
+	// as "gitlab.example.com".
+	Hosts map[string]Host `json:"hosts,omitempty"`
+}
+
+// Host is a self-managed git host, or credentials for a public one.
+type Host struct {
+	// Type is the kind of forge the host runs: "gitlab", "gitea" (also
+	// "forgejo") for Gitea-compatible hosts, or "git" for any host serving
+	// git over smart HTTP.
+	Type string `json:"type"`
+	// URL is the host's base URL, when it is not https://<host>.
+	URL   string `json:"url,omitempty"`
+	Token string `json:"token,omitempty"`
+}
+
+// hasToken reports whether the config holds any credentials.
+func (c *Config) hasToken() bool {
+	if c.GitHubToken != "" {
+		return true
+	}
+	for _, h := range c.Hosts {
+		if h.Token != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// BranchEntry is a cached default branch and when it was last confirmed.
+type BranchEntry struct {
+	Name    string    `json:"name"`
+	Checked time.Time `json:"checked"`
+}
+
+// DefaultBranch returns the cached default branch of repo, if there is one
+// younger than BranchTTL.
+func (c *Config) DefaultBranch(repo string, now time.Time) (string, bool) {
+	entry, ok := c.Branches[strings.ToLower(repo)]
+	if !ok || now.Sub(entry.Checked) > BranchTTL {
+		return "", false
+	}
+	return entry.Name, true
+}
+
+// SetDefaultBranch records repo's default branch as confirmed at now, and
+// drops entries older than BranchTTL so the cache does not grow forever.
+func (c *Config) SetDefaultBranch(repo, branch string, now time.Time) {
+	if c.Branches == nil {
+		c.Branches = make(map[string]BranchEntry)
+	}
+	for name, entry := range c.Branches {
+		if now.Sub(entry.Checked) > BranchTTL {
+			delete(c.Branches, name)
+		}
+	}
+	c.Branches[strings.ToLower(repo)] = BranchEntry{Name: branch, Checked: now}
+}
+
+// SearchDefaults are search options applied unless overridden on the
+// command line.
+type SearchDefaults struct {
+	Exclude  []string `json:"exclude,omitempty"`
+	NoVendor bool     `json:"no_vendor,omitempty"`
+	NoTests  bool     `json:"no_tests,omitempty"`
 }
 
 func Load(path string) (*Config, error) {
@@ -45,7 +122,28 @@ func Save(path string, cfg *Config) error {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0644)
+	// A config holding a token must only be readable by its owner. The file
+	// is written to a temp file, created 0600, and renamed over the old one,
+	// so a token is never readable under the mode of an existing file.
+	f, err := os.CreateTemp(dir, ".config-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if !cfg.hasToken() {
+		if err := f.Chmod(0644); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
 }
 
 func DefaultPath() (string, error) {
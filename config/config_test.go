@@ -6,7 +6,9 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig_NonExistent(t *testing.T) {
@@ -56,6 +58,177 @@ func TestSaveAndLoad(t *testing.T) {
 	}
 }
 
+func TestSaveAndLoad_SearchDefaults(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	cfg := &Config{
+		Crates: map[string]string{},
+		Search: SearchDefaults{Exclude: []string{"*.lock"}, NoVendor: true},
+	}
+	if err := Save(path, cfg); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !loaded.Search.NoVendor || loaded.Search.NoTests {
+		t.Errorf("Load() Search = %+v, want NoVendor only", loaded.Search)
+	}
+	if len(loaded.Search.Exclude) != 1 || loaded.Search.Exclude[0] != "*.lock" {
+		t.Errorf("Load() Search.Exclude = %v, want [*.lock]", loaded.Search.Exclude)
+	}
+}
+
+func TestSave_OmitsEmptySearchDefaults(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	if err := Save(path, &Config{Crates: map[string]string{"serde": "serde-rs/serde"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(data), "search") {
+		t.Errorf("Save() wrote %s, want no search section", data)
+	}
+}
+
+func TestDefaultBranch_Expiry(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &Config{}
+
+	if _, ok := cfg.DefaultBranch("grafana/alloy", now); ok {
+		t.Error("DefaultBranch() on empty config ok = true, want false")
+	}
+
+	cfg.SetDefaultBranch("Grafana/Alloy", "main", now)
+	if got, ok := cfg.DefaultBranch("grafana/alloy", now.Add(BranchTTL-time.Minute)); !ok || got != "main" {
+		t.Errorf("DefaultBranch() = %q, %v, want %q, true", got, ok, "main")
+	}
+	if _, ok := cfg.DefaultBranch("grafana/alloy", now.Add(BranchTTL+time.Minute)); ok {
+		t.Error("DefaultBranch() after expiry ok = true, want false")
+	}
+}
+
+func TestSetDefaultBranch_PrunesExpired(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &Config{}
+	cfg.SetDefaultBranch("old/repo", "main", now)
+	cfg.SetDefaultBranch("recent/repo", "main", now.Add(BranchTTL/2))
+
+	cfg.SetDefaultBranch("grafana/alloy", "main", now.Add(BranchTTL+time.Minute))
+	if _, ok := cfg.Branches["old/repo"]; ok {
+		t.Error("SetDefaultBranch() kept an expired entry")
+	}
+	if len(cfg.Branches) != 2 {
+		t.Errorf("len(Branches) = %d, want 2", len(cfg.Branches))
+	}
+}
+
+func TestSaveAndLoad_Branches(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	cfg := &Config{Crates: map[string]string{}}
+	cfg.SetDefaultBranch("torvalds/linux", "master", now)
+	if err := Save(path, cfg); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got, ok := loaded.DefaultBranch("torvalds/linux", now); !ok || got != "master" {
+		t.Errorf("DefaultBranch() after Load = %q, %v, want %q, true", got, ok, "master")
+	}
+}
+
+func TestSave_TokenPermissions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	if err := Save(path, &Config{Crates: map[string]string{}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := Save(path, &Config{Crates: map[string]string{}, GitHubToken: "ghp_test"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("Save() with token wrote mode %o, want 600", perm)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.GitHubToken != "ghp_test" {
+		t.Errorf("Load() GitHubToken = %q, want %q", loaded.GitHubToken, "ghp_test")
+	}
+}
+
+func TestSave_ReplacesFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	for range 2 {
+		if err := Save(path, &Config{Crates: map[string]string{"serde": "serde-rs/serde"}}); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0644 {
+		t.Errorf("Save() without token wrote mode %o, want 644", perm)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Save() left %d files in the config dir, want 1", len(entries))
+	}
+}
+
+func TestSave_HostTokenPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	cfg := &Config{Hosts: map[string]Host{"gitlab.example.com": {Type: "gitlab", Token: "glpat-test"}}}
+	if err := Save(path, cfg); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("Save() with a host token wrote mode %o, want 600", perm)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := loaded.Hosts["gitlab.example.com"]; got != cfg.Hosts["gitlab.example.com"] {
+		t.Errorf("Load() host = %+v, want %+v", got, cfg.Hosts["gitlab.example.com"])
+	}
+}
+
 func TestSave_CreatesDirectory(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "subdir", "config.json")
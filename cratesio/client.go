@@ -1,5 +1,5 @@
 // ABOUTME: HTTP client for crates.io API.
-// ABOUTME: Looks up Rust crates and extracts their GitHub repository URLs.
+// ABOUTME: Looks up Rust crates and extracts their repository, on GitHub or another forge.
 
 package cratesio
 
@@ -8,7 +8,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
+
+	"github.com/bartriepe/my-docs/httpretry"
 )
 
 const baseURL = "https://crates.io/api/v1/crates"
@@ -35,7 +38,7 @@ func Lookup(crateName string) (*Response, error) {
 	}
 	req.Header.Set("User-Agent", "my-docs/1.0 (https://github.com/serialexp/my-docs)")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpretry.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -56,32 +59,43 @@ func Lookup(crateName string) (*Response, error) {
 	return &result, nil
 }
 
-func ExtractGitHubRepo(resp *Response) (string, error) {
+// ExtractRepo returns the crate's repository in the form repospec.Parse
+// accepts: "owner/repo" for GitHub and "host/path" for other hosts, such as
+// "gitlab.com/group/sub/project" or "codeberg.org/owner/repo". Links into a
+// file or directory of the repo are trimmed back to the repo. giteaHosts
+// names the hosts known to run Gitea or Forgejo, whose links are trimmed
+// like GitHub's.
+func ExtractRepo(resp *Response, giteaHosts map[string]bool) (string, error) {
 	if resp.Crate.Repository == nil || *resp.Crate.Repository == "" {
 		return "", errors.New("crate has no repository URL")
 	}
+	raw := *resp.Crate.Repository
 
-	repoURL := *resp.Crate.Repository
-
-	if !strings.Contains(repoURL, "github.com") {
-		return "", fmt.Errorf("repository %q is not on GitHub", repoURL)
+	u, err := url.Parse(strings.TrimPrefix(raw, "git+"))
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid repository URL: %q", raw)
 	}
-
-	// Extract owner/repo from URLs like:
-	// - https://github.com/owner/repo
-	// - https://github.com/owner/repo/
-	// - https://github.com/owner/repo/tree/master/subdir
-	repoURL = strings.TrimPrefix(repoURL, "https://")
-	repoURL = strings.TrimPrefix(repoURL, "http://")
-	repoURL = strings.TrimPrefix(repoURL, "github.com/")
-
-	parts := strings.Split(repoURL, "/")
-	if len(parts) < 2 {
-		return "", fmt.Errorf("invalid GitHub URL: %q", *resp.Crate.Repository)
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+
+	// GitLab links into a repo through "/-/", as in
+	// https://gitlab.com/group/project/-/tree/main/subdir.
+	repoPath, _, _ := strings.Cut(u.Path, "/-/")
+	parts := strings.Split(strings.Trim(repoPath, "/"), "/")
+	if len(parts) > 2 && (host == "github.com" || giteaHosts[host]) {
+		// GitHub and Gitea do not nest repos in groups, and link into a
+		// repo as https://github.com/owner/repo/tree/master/subdir and
+		// https://codeberg.org/owner/repo/src/branch/main/subdir. Other
+		// hosts may be GitLab, where group/src/tool is a repo.
+		parts = parts[:2]
 	}
+	if len(parts) < 2 || parts[0] == "" {
+		return "", fmt.Errorf("invalid repository URL: %q", raw)
+	}
+	parts[len(parts)-1] = strings.TrimSuffix(parts[len(parts)-1], ".git")
 
-	owner := parts[0]
-	repo := strings.TrimSuffix(parts[1], ".git")
-
-	return owner + "/" + repo, nil
+	repo := strings.Join(parts, "/")
+	if host == "github.com" {
+		return repo, nil
+	}
+	return host + "/" + repo, nil
 }
@@ -21,12 +21,12 @@ func TestParseResponse_WithRepository(t *testing.T) {
 		t.Fatalf("Failed to parse response: %v", err)
 	}
 
-	repo, err := ExtractGitHubRepo(&resp)
+	repo, err := ExtractRepo(&resp, nil)
 	if err != nil {
-		t.Fatalf("ExtractGitHubRepo() error = %v", err)
+		t.Fatalf("ExtractRepo() error = %v", err)
 	}
 	if repo != "alacritty/alacritty" {
-		t.Errorf("ExtractGitHubRepo() = %q, want %q", repo, "alacritty/alacritty")
+		t.Errorf("ExtractRepo() = %q, want %q", repo, "alacritty/alacritty")
 	}
 }
 
@@ -43,12 +43,12 @@ func TestParseResponse_WithTrailingSlash(t *testing.T) {
 		t.Fatalf("Failed to parse response: %v", err)
 	}
 
-	repo, err := ExtractGitHubRepo(&resp)
+	repo, err := ExtractRepo(&resp, nil)
 	if err != nil {
-		t.Fatalf("ExtractGitHubRepo() error = %v", err)
+		t.Fatalf("ExtractRepo() error = %v", err)
 	}
 	if repo != "serde-rs/serde" {
-		t.Errorf("ExtractGitHubRepo() = %q, want %q", repo, "serde-rs/serde")
+		t.Errorf("ExtractRepo() = %q, want %q", repo, "serde-rs/serde")
 	}
 }
 
@@ -66,12 +66,12 @@ func TestParseResponse_WithSubdirectory(t *testing.T) {
 		t.Fatalf("Failed to parse response: %v", err)
 	}
 
-	repo, err := ExtractGitHubRepo(&resp)
+	repo, err := ExtractRepo(&resp, nil)
 	if err != nil {
-		t.Fatalf("ExtractGitHubRepo() error = %v", err)
+		t.Fatalf("ExtractRepo() error = %v", err)
 	}
 	if repo != "tokio-rs/tokio" {
-		t.Errorf("ExtractGitHubRepo() = %q, want %q", repo, "tokio-rs/tokio")
+		t.Errorf("ExtractRepo() = %q, want %q", repo, "tokio-rs/tokio")
 	}
 }
 
@@ -88,28 +88,50 @@ func TestParseResponse_NoRepository(t *testing.T) {
 		t.Fatalf("Failed to parse response: %v", err)
 	}
 
-	_, err := ExtractGitHubRepo(&resp)
+	_, err := ExtractRepo(&resp, nil)
 	if err == nil {
-		t.Error("ExtractGitHubRepo() error = nil, want error for missing repository")
+		t.Error("ExtractRepo() error = nil, want error for missing repository")
 	}
 }
 
-func TestParseResponse_NonGitHubRepository(t *testing.T) {
-	jsonData := `{
-		"crate": {
-			"name": "some-crate",
-			"repository": "https://gitlab.com/foo/bar"
-		}
-	}`
+func TestExtractRepo_OtherHosts(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://gitlab.com/foo/bar", "gitlab.com/foo/bar"},
+		{"https://gitlab.com/group/sub/project.git", "gitlab.com/group/sub/project"},
+		{"https://gitlab.com/group/project/-/tree/main/crates/core", "gitlab.com/group/project"},
+		{"git+https://GitLab.Example.com/tools/cli/", "gitlab.example.com/tools/cli"},
+		{"https://www.github.com/owner/repo.git", "owner/repo"},
+		{"https://codeberg.org/owner/repo", "codeberg.org/owner/repo"},
+		{"https://codeberg.org/owner/repo/src/branch/main/crates/core", "codeberg.org/owner/repo"},
+		{"https://gitlab.com/org/src/tool", "gitlab.com/org/src/tool"},
+		{"https://git.example.org/org/src/tool", "git.example.org/org/src/tool"},
+		{"https://forgejo.example.org/owner/repo/src/tag/v1.0/lib", "forgejo.example.org/owner/repo"},
+	}
 
-	var resp Response
-	if err := json.Unmarshal([]byte(jsonData), &resp); err != nil {
-		t.Fatalf("Failed to parse response: %v", err)
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			url := tt.url
+			resp := Response{Crate: Crate{Name: "some-crate", Repository: &url}}
+			got, err := ExtractRepo(&resp, map[string]bool{"codeberg.org": true, "forgejo.example.org": true})
+			if err != nil {
+				t.Fatalf("ExtractRepo() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ExtractRepo() = %q, want %q", got, tt.want)
+			}
+		})
 	}
+}
 
-	_, err := ExtractGitHubRepo(&resp)
-	if err == nil {
-		t.Error("ExtractGitHubRepo() error = nil, want error for non-GitHub repository")
+func TestExtractRepo_Invalid(t *testing.T) {
+	for _, url := range []string{"not a url", "https://gitlab.com/solo"} {
+		resp := Response{Crate: Crate{Name: "some-crate", Repository: &url}}
+		if _, err := ExtractRepo(&resp, nil); err == nil {
+			t.Errorf("ExtractRepo(%q) error = nil, want error", url)
+		}
 	}
 }
 
@@ -0,0 +1,71 @@
+// ABOUTME: Sends API requests to self-hosted forges such as GitLab and Gitea.
+// ABOUTME: Authenticates with the host's token header and explains 404/401/403 responses in terms of access.
+
+package forge
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/bartriepe/my-docs/httpretry"
+)
+
+// API is the HTTP API of one forge host.
+type API struct {
+	// BaseURL is the host's root URL, e.g. "https://codeberg.org".
+	BaseURL string
+	// Token is sent in AuthHeader, after AuthPrefix; empty for anonymous
+	// access.
+	Token      string
+	AuthHeader string
+	AuthPrefix string
+}
+
+// Get fetches rawURL and returns the response if it is a 200. what describes
+// the request in errors.
+func (a API) Get(rawURL, what string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "my-docs/1.0")
+	if a.Token != "" {
+		req.Header.Set(a.AuthHeader, a.AuthPrefix+a.Token)
+	}
+
+	resp, err := httpretry.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, a.StatusError(resp, what)
+	}
+	return resp, nil
+}
+
+// StatusError explains a failed response. Like GitHub, GitLab and Gitea
+// answer 404 for private repos the caller cannot see.
+func (a API) StatusError(resp *http.Response, what string) error {
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		if a.Token == "" {
+			return fmt.Errorf("%s: not found (or private: configure a token for %s)", what, a.Host())
+		}
+		return fmt.Errorf("%s: not found (or not authorized: the token has no access to it)", what)
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%s: not authorized: %s rejected the token", what, a.Host())
+	case http.StatusForbidden:
+		return fmt.Errorf("%s: not authorized (HTTP 403)", what)
+	}
+	return fmt.Errorf("%s: HTTP %d", what, resp.StatusCode)
+}
+
+// Host is the host name of BaseURL, for naming it in errors.
+func (a API) Host() string {
+	if u, err := url.Parse(a.BaseURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return a.BaseURL
+}
@@ -0,0 +1,62 @@
+// ABOUTME: Tests for requests to self-hosted forge APIs.
+// ABOUTME: Verifies the token header and how 404/401/403 responses are explained.
+
+package forge
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIGet(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	a := API{BaseURL: srv.URL, Token: "secret", AuthHeader: "Authorization", AuthPrefix: "token "}
+	resp, err := a.Get(srv.URL+"/ok", "could not fetch ok")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "token secret" {
+		t.Errorf("Get() sent Authorization %q, want %q", body, "token secret")
+	}
+
+	if _, err := a.Get(srv.URL+"/missing", "could not fetch missing"); err == nil || !strings.Contains(err.Error(), "the token has no access") {
+		t.Errorf("Get() of a 404 with a token = %v, want it to blame the token's access", err)
+	}
+}
+
+func TestAPIStatusError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		token  string
+		want   string
+	}{
+		{"404 anonymous", http.StatusNotFound, "", "not found (or private: configure a token for git.example.com)"},
+		{"404 with token", http.StatusNotFound, "tok", "not found (or not authorized: the token has no access"},
+		{"401", http.StatusUnauthorized, "tok", "not authorized: git.example.com rejected the token"},
+		{"403", http.StatusForbidden, "tok", "not authorized (HTTP 403)"},
+		{"500", http.StatusInternalServerError, "", "HTTP 500"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := API{BaseURL: "https://git.example.com", Token: tt.token}
+			err := a.StatusError(&http.Response{StatusCode: tt.status}, "a/b")
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("StatusError() = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// ABOUTME: Common interface for the git hosts my-docs reads from.
+// ABOUTME: Defines the tree listing types every provider returns.
+
+package forge
+
+import "io"
+
+// Provider reads repositories on one git host. Repos are named by their path
+// on the host, such as "owner/repo" or "group/subgroup/project".
+type Provider interface {
+	// DefaultBranch returns the branch a repo's HEAD points at.
+	DefaultBranch(repo string) (string, error)
+	// OpenFile opens a file at a branch, tag or commit for streaming.
+	OpenFile(repo, ref, path string) (io.ReadCloser, error)
+	// Tree lists every file and directory of a repo at a ref.
+	Tree(repo, ref string) (*Tree, error)
+	// OpenTarball opens a gzipped tarball of a repo at a ref. Paths in it sit
+	// under a single top-level directory.
+	OpenTarball(repo, ref string) (io.ReadCloser, error)
+}
+
+// TreeEntry is one file, directory or submodule of a repository tree.
+type TreeEntry struct {
+	Path string `json:"path"`
+	// Type is "blob" for files, "tree" for directories and "commit" for
+	// submodules.
+	Type string `json:"type"`
+	// Size is the size of a blob in bytes, or -1 when the host does not
+	// report sizes in listings.
+	Size int64 `json:"size"`
+	// SHA is the object ID of the blob, directory or submodule commit, equal
+	// between refs when the content is.
+	SHA string `json:"sha"`
+}
+
+// Tree is the recursive listing of a repository at one ref.
+type Tree struct {
+	Entries []TreeEntry `json:"tree"`
+	// Truncated is set when the host listed only part of the repository.
+	Truncated bool `json:"truncated"`
+}
+
+// ReadFile reads a whole file through p.
+func ReadFile(p Provider, repo, ref, path string) (string, error) {
+	body, err := p.OpenFile(repo, ref, path)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// Release is a published release of a repository.
+type Release struct {
+	Tag  string
+	Name string
+	// Body is the release notes, usually Markdown.
+	Body       string
+	Prerelease bool
+	// Published is when the release was published, as an RFC 3339 timestamp.
+	Published string
+	URL       string
+}
+
+// Releaser is implemented by providers whose host publishes releases.
+type Releaser interface {
+	// ReleasePage returns one page of a repo's releases, newest first, and
+	// whether more pages follow. Pages count from 1. Drafts are left out.
+	ReleasePage(repo string, page int) ([]Release, bool, error)
+}
+
+// Releases pages through a repo's releases, newest first, until it has
+// limit of them, there are no more, or done reports true for the page just
+// read. done may be nil.
+func Releases(r Releaser, repo string, limit int, done func(page []Release) bool) ([]Release, error) {
+	var releases []Release
+	for page := 1; len(releases) < limit; page++ {
+		batch, more, err := r.ReleasePage(repo, page)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, batch...)
+		if !more || (done != nil && done(batch)) {
+			break
+		}
+	}
+	return releases[:min(limit, len(releases))], nil
+}
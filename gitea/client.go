@@ -0,0 +1,199 @@
+// ABOUTME: Client for the Gitea API, also served by Forgejo and Codeberg.
+// ABOUTME: Reads raw files, lists trees and releases, finds default branches and downloads archives.
+
+package gitea
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bartriepe/my-docs/forge"
+)
+
+// treePageSize is how many entries a tree listing requests per page, the
+// most Gitea serves by default.
+const treePageSize = 1000
+
+// maxTreePages caps how many pages a tree listing reads.
+const maxTreePages = 50
+
+// Client reads repositories on one Gitea-compatible host. Repos are named
+// "owner/repo".
+type Client struct {
+	// BaseURL is the host's root URL, e.g. "https://codeberg.org".
+	BaseURL string
+	// Token is an access token; empty for anonymous access.
+	Token string
+}
+
+var (
+	_ forge.Provider = (*Client)(nil)
+	_ forge.Releaser = (*Client)(nil)
+)
+
+func New(baseURL, token string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), Token: token}
+}
+
+func (c *Client) BuildRepoURL(repo string) string {
+	return c.BaseURL + "/api/v1/repos/" + repo
+}
+
+func (c *Client) BuildFileURL(repo, ref, path string) string {
+	return c.BuildRepoURL(repo) + "/raw/" + escapePath(path) + "?ref=" + url.QueryEscape(ref)
+}
+
+func (c *Client) BuildTreeURL(repo, ref string, page int) string {
+	params := url.Values{}
+	params.Set("recursive", "true")
+	params.Set("per_page", strconv.Itoa(treePageSize))
+	params.Set("page", strconv.Itoa(page))
+	return c.BuildRepoURL(repo) + "/git/trees/" + url.PathEscape(ref) + "?" + params.Encode()
+}
+
+func (c *Client) BuildArchiveURL(repo, ref string) string {
+	return c.BuildRepoURL(repo) + "/archive/" + url.PathEscape(ref) + ".tar.gz"
+}
+
+// releasesPerPage is Gitea's default maximum page size. Servers may be
+// configured lower, so paging follows the Link header rather than page sizes.
+const releasesPerPage = 50
+
+func (c *Client) BuildReleasesURL(repo string, page int) string {
+	return c.BuildRepoURL(repo) + "/releases?limit=" + strconv.Itoa(releasesPerPage) + "&page=" + strconv.Itoa(page)
+}
+
+// escapePath escapes each segment of a slash-separated path.
+func escapePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// api is the host's API, authenticated with Token.
+func (c *Client) api() forge.API {
+	return forge.API{BaseURL: c.BaseURL, Token: c.Token, AuthHeader: "Authorization", AuthPrefix: "token "}
+}
+
+// get sends an authenticated GET. Non-200 responses are closed and turned
+// into an error describing what was requested.
+func (c *Client) get(rawURL, what string) (*http.Response, error) {
+	return c.api().Get(rawURL, what)
+}
+
+// getJSON fetches rawURL and decodes its body into v.
+func (c *Client) getJSON(rawURL, what string, v any) error {
+	resp, err := c.get(rawURL, what)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (c *Client) DefaultBranch(repo string) (string, error) {
+	var r struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := c.getJSON(c.BuildRepoURL(repo), fmt.Sprintf("could not look up repo %s", repo), &r); err != nil {
+		return "", err
+	}
+	if r.DefaultBranch == "" {
+		return "", fmt.Errorf("repo %s has no default branch", repo)
+	}
+	return r.DefaultBranch, nil
+}
+
+func (c *Client) OpenFile(repo, ref, path string) (io.ReadCloser, error) {
+	resp, err := c.get(c.BuildFileURL(repo, ref, path), fmt.Sprintf("could not fetch %s/%s at %s", repo, path, ref))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Tree lists a repo page by page. Gitea marks every page but the last as
+// truncated.
+func (c *Client) Tree(repo, ref string) (*forge.Tree, error) {
+	tree := &forge.Tree{}
+	for page := 1; ; page++ {
+		if page > maxTreePages {
+			tree.Truncated = true
+			break
+		}
+		var resp forge.Tree
+		if err := c.getJSON(c.BuildTreeURL(repo, ref, page), fmt.Sprintf("could not list %s at %s", repo, ref), &resp); err != nil {
+			return nil, err
+		}
+		tree.Entries = append(tree.Entries, resp.Entries...)
+		if !resp.Truncated || len(resp.Entries) == 0 {
+			break
+		}
+	}
+	return tree, nil
+}
+
+func (c *Client) OpenTarball(repo, ref string) (io.ReadCloser, error) {
+	resp, err := c.get(c.BuildArchiveURL(repo, ref), fmt.Sprintf("could not download %s at %s", repo, ref))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ReleasePage returns one page of a repo's releases, newest first, and
+// whether more follow.
+func (c *Client) ReleasePage(repo string, page int) ([]forge.Release, bool, error) {
+	resp, err := c.get(c.BuildReleasesURL(repo, page), fmt.Sprintf("could not list releases of %s", repo))
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	var releases []struct {
+		TagName     string `json:"tag_name"`
+		Name        string `json:"name"`
+		Body        string `json:"body"`
+		Draft       bool   `json:"draft"`
+		Prerelease  bool   `json:"prerelease"`
+		PublishedAt string `json:"published_at"`
+		HTMLURL     string `json:"html_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, false, err
+	}
+
+	var out []forge.Release
+	for _, r := range releases {
+		if r.Draft {
+			continue
+		}
+		out = append(out, forge.Release{
+			Tag:        r.TagName,
+			Name:       r.Name,
+			Body:       r.Body,
+			Prerelease: r.Prerelease,
+			Published:  r.PublishedAt,
+			URL:        r.HTMLURL,
+		})
+	}
+	return out, hasNextPage(resp.Header.Get("Link")), nil
+}
+
+// hasNextPage reports whether a Link header, as in
+// `<https://codeberg.org/api/v1/...&page=2>; rel="next"`, names a next page.
+func hasNextPage(link string) bool {
+	for _, part := range strings.Split(link, ",") {
+		if strings.Contains(part, `rel="next"`) {
+			return true
+		}
+	}
+	return false
+}
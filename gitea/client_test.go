@@ -0,0 +1,117 @@
+// ABOUTME: Tests for the Gitea API client.
+// ABOUTME: Verifies URL building, tree paging and release listing against an httptest server.
+
+package gitea
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bartriepe/my-docs/forge"
+)
+
+func TestBuildURLs(t *testing.T) {
+	c := New("https://codeberg.org/", "")
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"repo", c.BuildRepoURL("forgejo/forgejo"), "https://codeberg.org/api/v1/repos/forgejo/forgejo"},
+		{"file", c.BuildFileURL("forgejo/forgejo", "v9.0", "docs/my file.md"), "https://codeberg.org/api/v1/repos/forgejo/forgejo/raw/docs/my%20file.md?ref=v9.0"},
+		{"tree", c.BuildTreeURL("forgejo/forgejo", "main", 2), "https://codeberg.org/api/v1/repos/forgejo/forgejo/git/trees/main?page=2&per_page=1000&recursive=true"},
+		{"archive", c.BuildArchiveURL("forgejo/forgejo", "v9.0"), "https://codeberg.org/api/v1/repos/forgejo/forgejo/archive/v9.0.tar.gz"},
+		{"releases", c.BuildReleasesURL("forgejo/forgejo", 2), "https://codeberg.org/api/v1/repos/forgejo/forgejo/releases?limit=50&page=2"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s URL = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+// newTestServer serves one repo with a two-page tree and two pages of releases.
+func newTestServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/repos/owner/repo":
+			fmt.Fprint(w, `{"full_name": "owner/repo", "default_branch": "trunk"}`)
+		case "/api/v1/repos/owner/repo/raw/docs/intro.md":
+			fmt.Fprintf(w, "intro at %s\n", r.URL.Query().Get("ref"))
+		case "/api/v1/repos/owner/repo/git/trees/trunk":
+			if r.URL.Query().Get("page") == "1" {
+				fmt.Fprint(w, `{"tree": [{"path": "docs", "type": "tree", "size": 0}, {"path": "docs/intro.md", "type": "blob", "size": 42}], "truncated": true}`)
+				return
+			}
+			fmt.Fprint(w, `{"tree": [{"path": "README.md", "type": "blob", "size": 7}], "truncated": false}`)
+		case "/api/v1/repos/owner/repo/releases":
+			if r.URL.Query().Get("page") == "1" {
+				w.Header().Set("Link", `<https://codeberg.org/api/v1/repos/owner/repo/releases?page=2>; rel="next",<https://codeberg.org/api/v1/repos/owner/repo/releases?page=2>; rel="last"`)
+				fmt.Fprint(w, `[
+					{"tag_name": "v2.0.0-rc1", "draft": true},
+					{"tag_name": "v1.1.0", "name": "1.1", "body": "Fixes", "published_at": "2026-01-02T00:00:00Z"}
+				]`)
+				return
+			}
+			w.Header().Set("Link", `<https://codeberg.org/api/v1/repos/owner/repo/releases?page=1>; rel="first"`)
+			fmt.Fprint(w, `[{"tag_name": "v1.0.0", "prerelease": true}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient(t *testing.T) {
+	srv := newTestServer(t)
+	c := New(srv.URL, "secret")
+
+	branch, err := c.DefaultBranch("owner/repo")
+	if err != nil || branch != "trunk" {
+		t.Errorf("DefaultBranch() = %q, %v, want %q", branch, err, "trunk")
+	}
+
+	body, err := c.OpenFile("owner/repo", "v1", "docs/intro.md")
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	content, _ := io.ReadAll(body)
+	body.Close()
+	if string(content) != "intro at v1\n" {
+		t.Errorf("OpenFile() content = %q, want %q", content, "intro at v1\n")
+	}
+
+	tree, err := c.Tree("owner/repo", "trunk")
+	if err != nil {
+		t.Fatalf("Tree() error = %v", err)
+	}
+	if len(tree.Entries) != 3 || tree.Truncated || tree.Entries[1].Size != 42 {
+		t.Errorf("Tree() = %+v, want both pages with sizes", tree)
+	}
+
+	releases, err := forge.Releases(c, "owner/repo", 10, nil)
+	if err != nil {
+		t.Fatalf("Releases() error = %v", err)
+	}
+	if len(releases) != 2 || releases[0].Tag != "v1.1.0" || releases[0].Body != "Fixes" || !releases[1].Prerelease {
+		t.Errorf("Releases() = %+v, want v1.1.0 and v1.0.0 without the draft", releases)
+	}
+}
+
+func TestClient_NotFound(t *testing.T) {
+	srv := newTestServer(t)
+
+	_, err := New(srv.URL, "").DefaultBranch("owner/repo")
+	if err == nil || !strings.Contains(err.Error(), "or private: configure a token") {
+		t.Errorf("DefaultBranch() without token error = %v, want a hint about tokens", err)
+	}
+}
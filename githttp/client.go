@@ -0,0 +1,496 @@
+// ABOUTME: Reads repositories from any git host over smart HTTP with protocol v2.
+// ABOUTME: Fetches one ref shallow and blobless for listings, then pulls single blobs on demand.
+
+package githttp
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bartriepe/my-docs/forge"
+	"github.com/bartriepe/my-docs/httpretry"
+)
+
+var commitID = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// Client reads repositories on one host. A repo's clone URL is BaseURL
+// followed by its name, e.g. "https://git.example.com" and "team/project.git".
+type Client struct {
+	BaseURL string
+	// Token is sent as the password of HTTP basic auth; empty for anonymous
+	// access.
+	Token string
+
+	mu sync.Mutex
+	// features holds each repo's fetch features, such as "shallow" and
+	// "filter", from its capability advertisement.
+	features map[string][]string
+	// snapshots holds listings by repo and commit, so cat does not refetch
+	// the trees for every file.
+	snapshots map[string]*snapshot
+	// commits holds the refs resolved so far, by repo and ref name, so the
+	// default branch found by DefaultBranch needs no second ls-refs.
+	commits map[string]string
+}
+
+var _ forge.Provider = (*Client)(nil)
+
+func New(baseURL, token string) *Client {
+	return &Client{
+		BaseURL:   strings.TrimSuffix(baseURL, "/"),
+		Token:     token,
+		features:  make(map[string][]string),
+		snapshots: make(map[string]*snapshot),
+		commits:   make(map[string]string),
+	}
+}
+
+// snapshot is the listing of a repo at one commit.
+type snapshot struct {
+	entries []forge.TreeEntry
+	// blobs maps file paths to blob IDs.
+	blobs map[string]string
+}
+
+func (c *Client) repoURL(repo string) string {
+	return c.BaseURL + "/" + repo
+}
+
+// send makes a request with protocol v2 requested. Non-200 responses are
+// closed and turned into an error.
+func (c *Client) send(req *http.Request, repo string) (*http.Response, error) {
+	req.Header.Set("User-Agent", "my-docs/1.0")
+	req.Header.Set("Git-Protocol", "version=2")
+	if c.Token != "" {
+		req.SetBasicAuth("git", c.Token)
+	}
+
+	resp, err := httpretry.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("repo %s not found at %s", repo, c.repoURL(repo))
+	case http.StatusUnauthorized, http.StatusForbidden:
+		if c.Token == "" {
+			return nil, fmt.Errorf("repo %s: not authorized (configure a token for the host)", repo)
+		}
+		return nil, fmt.Errorf("repo %s: not authorized: the host rejected the token", repo)
+	}
+	return nil, fmt.Errorf("repo %s: HTTP %d", repo, resp.StatusCode)
+}
+
+// fetchFeatures returns the repo's fetch features, reading its capability
+// advertisement the first time.
+func (c *Client) fetchFeatures(repo string) ([]string, error) {
+	c.mu.Lock()
+	features, ok := c.features[repo]
+	c.mu.Unlock()
+	if ok {
+		return features, nil
+	}
+
+	req, err := http.NewRequest("GET", c.repoURL(repo)+"/info/refs?service=git-upload-pack", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(req, repo)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	features, err = parseCapabilities(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("repo %s: %w", repo, err)
+	}
+	c.mu.Lock()
+	c.features[repo] = features
+	c.mu.Unlock()
+	return features, nil
+}
+
+// parseCapabilities reads a protocol v2 capability advertisement and returns
+// the features of its fetch command.
+func parseCapabilities(r io.Reader) ([]string, error) {
+	pkts := &pktReader{r: r}
+	line, _, err := pkts.nextLine()
+	if err != nil {
+		return nil, err
+	}
+	// Smart HTTP servers may prefix the advertisement with a service line.
+	if strings.HasPrefix(line, "# service=") {
+		if _, kind, err := pkts.next(); err != nil || kind != pktFlush {
+			return nil, fmt.Errorf("malformed capability advertisement")
+		}
+		if line, _, err = pkts.nextLine(); err != nil {
+			return nil, err
+		}
+	}
+	if line != "version 2" {
+		return nil, fmt.Errorf("the server does not support git protocol v2")
+	}
+
+	var features []string
+	fetch := false
+	for {
+		line, kind, err := pkts.nextLine()
+		if err != nil {
+			return nil, err
+		}
+		if kind == pktFlush {
+			break
+		}
+		if name, value, _ := strings.Cut(line, "="); name == "fetch" {
+			fetch = true
+			features = strings.Fields(value)
+		}
+	}
+	if !fetch {
+		return nil, fmt.Errorf("the server does not offer the fetch command")
+	}
+	return features, nil
+}
+
+// command runs a protocol v2 command with the given arguments and returns
+// the response body.
+func (c *Client) command(repo, name string, args []string) (io.ReadCloser, error) {
+	var body strings.Builder
+	body.WriteString(pktLine("command=" + name + "\n"))
+	body.WriteString(delimPkt)
+	for _, arg := range args {
+		body.WriteString(pktLine(arg + "\n"))
+	}
+	body.WriteString(flushPkt)
+
+	req, err := http.NewRequest("POST", c.repoURL(repo)+"/git-upload-pack", strings.NewReader(body.String()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-git-upload-pack-request")
+	req.Header.Set("Accept", "application/x-git-upload-pack-result")
+	resp, err := c.send(req, repo)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ref is one line of an ls-refs response.
+type ref struct {
+	name   string
+	id     string
+	symref string
+	peeled string
+}
+
+// lsRefs lists the refs starting with any of prefixes.
+func (c *Client) lsRefs(repo string, prefixes ...string) ([]ref, error) {
+	args := []string{"symrefs", "peel"}
+	for _, p := range prefixes {
+		args = append(args, "ref-prefix "+p)
+	}
+	body, err := c.command(repo, "ls-refs", args)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var refs []ref
+	pkts := &pktReader{r: body}
+	for {
+		line, kind, err := pkts.nextLine()
+		if err != nil {
+			return nil, err
+		}
+		if kind == pktFlush {
+			return refs, nil
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("malformed ls-refs line %q", line)
+		}
+		r := ref{id: fields[0], name: fields[1]}
+		for _, attr := range fields[2:] {
+			if v, ok := strings.CutPrefix(attr, "symref-target:"); ok {
+				r.symref = v
+			}
+			if v, ok := strings.CutPrefix(attr, "peeled:"); ok {
+				r.peeled = v
+			}
+		}
+		refs = append(refs, r)
+	}
+}
+
+func (c *Client) DefaultBranch(repo string) (string, error) {
+	refs, err := c.lsRefs(repo, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	for _, r := range refs {
+		if branch, ok := strings.CutPrefix(r.symref, "refs/heads/"); ok && r.name == "HEAD" {
+			c.mu.Lock()
+			c.commits[repo+"@"+branch] = r.id
+			c.mu.Unlock()
+			return branch, nil
+		}
+	}
+	return "", fmt.Errorf("repo %s has no default branch", repo)
+}
+
+// resolve turns a branch, tag, HEAD or full commit ID into a commit ID.
+func (c *Client) resolve(repo, name string) (string, error) {
+	if commitID.MatchString(name) {
+		return name, nil
+	}
+	c.mu.Lock()
+	id, ok := c.commits[repo+"@"+name]
+	c.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	candidates := []string{"refs/heads/" + name, "refs/tags/" + name}
+	if name == "HEAD" {
+		candidates = []string{"HEAD"}
+	}
+	refs, err := c.lsRefs(repo, candidates...)
+	if err != nil {
+		return "", err
+	}
+	for _, want := range candidates {
+		for _, r := range refs {
+			if r.name != want {
+				continue
+			}
+			id := r.id
+			if r.peeled != "" {
+				id = r.peeled
+			}
+			c.mu.Lock()
+			c.commits[repo+"@"+name] = id
+			c.mu.Unlock()
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("no branch or tag %q in %s (commits must be given as full 40-character IDs)", name, repo)
+}
+
+// fetch requests the objects in wants. shallow limits a commit to itself
+// without history; blobless leaves out file contents when the server
+// supports filters.
+func (c *Client) fetch(repo string, wants []string, shallow, blobless bool) (map[string]object, error) {
+	features, err := c.fetchFeatures(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var args []string
+	for _, id := range wants {
+		args = append(args, "want "+id)
+	}
+	if shallow && slices.Contains(features, "shallow") {
+		args = append(args, "deepen 1")
+	}
+	if blobless && slices.Contains(features, "filter") {
+		args = append(args, "filter blob:none")
+	}
+	args = append(args, "no-progress", "done")
+
+	body, err := c.command(repo, "fetch", args)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	// Skip the sections before the pack, such as shallow-info.
+	pkts := &pktReader{r: body}
+	for {
+		line, kind, err := pkts.nextLine()
+		if err != nil {
+			return nil, fmt.Errorf("repo %s: reading fetch response: %w", repo, err)
+		}
+		if kind == pktData && line == "packfile" {
+			break
+		}
+		if kind == pktData && strings.HasPrefix(line, "ERR ") {
+			return nil, fmt.Errorf("repo %s: %s", repo, strings.TrimPrefix(line, "ERR "))
+		}
+	}
+	objects, err := readPack(&sidebandReader{pkts: pkts})
+	if err != nil {
+		return nil, fmt.Errorf("repo %s: %w", repo, err)
+	}
+	return objects, nil
+}
+
+// snapshot lists a repo at a ref from a shallow, blobless fetch.
+func (c *Client) snapshot(repo, ref string) (*snapshot, error) {
+	commit, err := c.resolve(repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	key := repo + "@" + commit
+	c.mu.Lock()
+	snap, ok := c.snapshots[key]
+	c.mu.Unlock()
+	if ok {
+		return snap, nil
+	}
+
+	objects, err := c.fetch(repo, []string{commit}, true, true)
+	if err != nil {
+		return nil, err
+	}
+	snap = &snapshot{blobs: make(map[string]string)}
+	err = walkCommit(objects, commit, func(p string, item treeItem) {
+		entry := forge.TreeEntry{Path: p, Size: -1, SHA: item.id}
+		switch item.mode {
+		case "40000":
+			entry.Type = "tree"
+		case "160000":
+			entry.Type = "commit"
+		default:
+			entry.Type = "blob"
+			snap.blobs[p] = item.id
+			if blob, ok := objects[item.id]; ok {
+				entry.Size = int64(len(blob.data))
+			}
+		}
+		snap.entries = append(snap.entries, entry)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("repo %s: %w", repo, err)
+	}
+
+	c.mu.Lock()
+	c.snapshots[key] = snap
+	c.mu.Unlock()
+	return snap, nil
+}
+
+// walkCommit calls visit for every entry under a commit's tree, parents
+// before their children.
+func walkCommit(objects map[string]object, commit string, visit func(path string, item treeItem)) error {
+	obj, ok := objects[commit]
+	if !ok || obj.typ != objCommit {
+		return fmt.Errorf("the server did not send commit %s", commit)
+	}
+	root, err := commitTree(obj.data)
+	if err != nil {
+		return err
+	}
+
+	var walk func(id, prefix string) error
+	walk = func(id, prefix string) error {
+		tree, ok := objects[id]
+		if !ok || tree.typ != objTree {
+			return fmt.Errorf("the server did not send tree %s", id)
+		}
+		items, err := parseTree(tree.data)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			p := path.Join(prefix, item.name)
+			visit(p, item)
+			if item.mode == "40000" {
+				if err := walk(item.id, p); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return walk(root, "")
+}
+
+func (c *Client) Tree(repo, ref string) (*forge.Tree, error) {
+	snap, err := c.snapshot(repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	return &forge.Tree{Entries: snap.entries}, nil
+}
+
+// OpenFile finds path in the ref's listing and fetches just its blob.
+func (c *Client) OpenFile(repo, ref, p string) (io.ReadCloser, error) {
+	snap, err := c.snapshot(repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := snap.blobs[p]
+	if !ok {
+		return nil, fmt.Errorf("could not fetch %s/%s at %s: no such file", repo, p, ref)
+	}
+
+	objects, err := c.fetch(repo, []string{id}, false, false)
+	if err != nil {
+		return nil, err
+	}
+	blob, ok := objects[id]
+	if !ok {
+		return nil, fmt.Errorf("repo %s: the server did not send blob %s", repo, id)
+	}
+	return io.NopCloser(bytes.NewReader(blob.data)), nil
+}
+
+// OpenTarball fetches the whole ref, without history, and packs its files
+// into a gzipped tarball under a single top-level directory.
+func (c *Client) OpenTarball(repo, ref string) (io.ReadCloser, error) {
+	commit, err := c.resolve(repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := c.fetch(repo, []string{commit}, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	top := path.Base(strings.TrimSuffix(repo, ".git")) + "-" + commit[:12]
+	var writeErr error
+	err = walkCommit(objects, commit, func(p string, item treeItem) {
+		blob, ok := objects[item.id]
+		if writeErr != nil || !ok || blob.typ != objBlob {
+			return
+		}
+		hdr := &tar.Header{Name: top + "/" + p, Mode: 0644, Size: int64(len(blob.data)), Typeflag: tar.TypeReg}
+		if item.mode == "120000" {
+			hdr = &tar.Header{Name: top + "/" + p, Linkname: string(blob.data), Typeflag: tar.TypeSymlink}
+		}
+		if writeErr = tw.WriteHeader(hdr); writeErr == nil && hdr.Typeflag == tar.TypeReg {
+			_, writeErr = tw.Write(blob.data)
+		}
+	})
+	if err == nil {
+		err = writeErr
+	}
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repo %s: %w", repo, err)
+	}
+	return io.NopCloser(&buf), nil
+}
@@ -0,0 +1,210 @@
+// ABOUTME: Tests for the smart HTTP client against git http-backend on an httptest server.
+// ABOUTME: Verifies ref resolution, blobless listings, single-blob fetches and tarballs.
+
+package githttp
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestServer serves a bare repo "team/project.git" through git
+// http-backend. The repo has a v1 tag and a later commit on main.
+func newTestServer(t *testing.T) *httptest.Server {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is not installed")
+	}
+	execPath, err := exec.Command(gitPath, "--exec-path").Output()
+	if err != nil {
+		t.Skipf("git --exec-path: %v", err)
+	}
+	backend := filepath.Join(strings.TrimSpace(string(execPath)), "git-http-backend")
+
+	env := []string{
+		"GIT_CONFIG_GLOBAL=/dev/null",
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+	}
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command(gitPath, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), env...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	bare := filepath.Join(root, "repos", "team", "project.git")
+	git(root, "init", "-q", "-b", "main", work)
+	write(filepath.Join(work, "README.md"), "version one\n")
+	write(filepath.Join(work, "docs", "guide", "intro.md"), strings.Repeat("intro line\n", 200))
+	git(work, "add", ".")
+	git(work, "commit", "-q", "-m", "first")
+	git(work, "tag", "-a", "v1", "-m", "v1")
+	write(filepath.Join(work, "README.md"), "version two\n")
+	write(filepath.Join(work, "docs", "guide", "intro.md"), strings.Repeat("intro line\n", 200)+"appended\n")
+	git(work, "commit", "-q", "-am", "second")
+	git(root, "clone", "-q", "--bare", work, bare)
+	git(bare, "config", "uploadpack.allowFilter", "true")
+	// Pack the objects so fetches are served with deltas.
+	git(bare, "repack", "-q", "-a", "-d", "-f")
+
+	srv := httptest.NewServer(&cgi.Handler{
+		Path: backend,
+		Env: append([]string{
+			"GIT_PROJECT_ROOT=" + filepath.Join(root, "repos"),
+			"GIT_HTTP_EXPORT_ALL=1",
+		}, env...),
+	})
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient(t *testing.T) {
+	srv := newTestServer(t)
+	c := New(srv.URL, "")
+	repo := "team/project.git"
+
+	branch, err := c.DefaultBranch(repo)
+	if err != nil || branch != "main" {
+		t.Errorf("DefaultBranch() = %q, %v, want %q", branch, err, "main")
+	}
+
+	tree, err := c.Tree(repo, "main")
+	if err != nil {
+		t.Fatalf("Tree() error = %v", err)
+	}
+	var paths []string
+	for _, e := range tree.Entries {
+		paths = append(paths, e.Type+" "+e.Path)
+		if e.Type == "blob" && e.Size != -1 {
+			t.Errorf("Tree() entry %s has size %d, want -1 from a blobless fetch", e.Path, e.Size)
+		}
+	}
+	want := "blob README.md,tree docs,tree docs/guide,blob docs/guide/intro.md"
+	if got := strings.Join(paths, ","); got != want {
+		t.Errorf("Tree() = %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		ref, path, want string
+	}{
+		{"main", "README.md", "version two\n"},
+		{"v1", "README.md", "version one\n"},
+		{"HEAD", "docs/guide/intro.md", strings.Repeat("intro line\n", 200) + "appended\n"},
+		{"v1", "docs/guide/intro.md", strings.Repeat("intro line\n", 200)},
+	}
+	for _, tt := range tests {
+		body, err := c.OpenFile(repo, tt.ref, tt.path)
+		if err != nil {
+			t.Errorf("OpenFile(%s, %s) error = %v", tt.ref, tt.path, err)
+			continue
+		}
+		content, _ := io.ReadAll(body)
+		body.Close()
+		if string(content) != tt.want {
+			t.Errorf("OpenFile(%s, %s) = %q, want %q", tt.ref, tt.path, content, tt.want)
+		}
+	}
+
+	if _, err := c.OpenFile(repo, "main", "missing.md"); err == nil {
+		t.Error("OpenFile() of a missing file error = nil, want error")
+	}
+	if _, err := c.Tree(repo, "v9"); err == nil || !strings.Contains(err.Error(), "no branch or tag") {
+		t.Errorf("Tree() of an unknown ref error = %v, want no branch or tag", err)
+	}
+}
+
+func TestClient_ReusesResolvedRefs(t *testing.T) {
+	srv := newTestServer(t)
+	backend := srv.Config.Handler
+	var commands []string
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		commands = append(commands, r.Method+" "+path.Base(r.URL.Path))
+		backend.ServeHTTP(w, r)
+	})
+	c := New(srv.URL, "")
+	repo := "team/project.git"
+
+	branch, err := c.DefaultBranch(repo)
+	if err != nil {
+		t.Fatalf("DefaultBranch() error = %v", err)
+	}
+	if _, err := c.Tree(repo, branch); err != nil {
+		t.Fatalf("Tree() error = %v", err)
+	}
+	if _, err := c.Tree(repo, branch); err != nil {
+		t.Fatalf("Tree() error = %v", err)
+	}
+	// ls-refs, the capability advertisement and one blobless fetch.
+	want := "POST git-upload-pack,GET refs,POST git-upload-pack"
+	if got := strings.Join(commands, ","); got != want {
+		t.Errorf("requests = %q, want %q", got, want)
+	}
+}
+
+func TestClient_OpenTarball(t *testing.T) {
+	srv := newTestServer(t)
+	c := New(srv.URL, "")
+
+	body, err := c.OpenTarball("team/project.git", "v1")
+	if err != nil {
+		t.Fatalf("OpenTarball() error = %v", err)
+	}
+	defer body.Close()
+	gz, err := gzip.NewReader(body)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+
+	files := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar Next() error = %v", err)
+		}
+		_, name, _ := strings.Cut(hdr.Name, "/")
+		content, _ := io.ReadAll(tr)
+		files[name] = string(content)
+	}
+	if len(files) != 2 || files["README.md"] != "version one\n" {
+		t.Errorf("OpenTarball() files = %v, want README.md and docs/guide/intro.md at v1", files)
+	}
+}
+
+func TestClient_NotFound(t *testing.T) {
+	srv := newTestServer(t)
+
+	_, err := New(srv.URL, "").DefaultBranch("team/missing.git")
+	if err == nil {
+		t.Error("DefaultBranch() of a missing repo error = nil, want error")
+	}
+}
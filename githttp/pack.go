@@ -0,0 +1,333 @@
+// ABOUTME: Parses git packfiles into objects, resolving deltas against earlier objects.
+// ABOUTME: Also decodes the commit and tree objects needed to walk a snapshot.
+
+package githttp
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type objType int
+
+// Object types as numbered in packfiles.
+const (
+	objCommit   objType = 1
+	objTree     objType = 2
+	objBlob     objType = 3
+	objTag      objType = 4
+	objOfsDelta objType = 6
+	objRefDelta objType = 7
+)
+
+func (t objType) String() string {
+	switch t {
+	case objCommit:
+		return "commit"
+	case objTree:
+		return "tree"
+	case objBlob:
+		return "blob"
+	case objTag:
+		return "tag"
+	}
+	return "type " + strconv.Itoa(int(t))
+}
+
+type object struct {
+	typ  objType
+	data []byte
+}
+
+// objectID is the hex SHA-1 git names an object by.
+func objectID(typ objType, data []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s %d\x00", typ, len(data))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// countingReader tracks the offset into the pack. Being an io.ByteReader
+// keeps zlib from reading past the end of each object.
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+// delta is a packed object stored as changes to a base object, named either
+// by its offset in the pack or by its ID.
+type delta struct {
+	offset     int64
+	baseOffset int64
+	baseID     string
+	data       []byte
+}
+
+// readPack reads a version 2 packfile and returns its objects by ID.
+func readPack(r io.Reader) (map[string]object, error) {
+	cr := &countingReader{r: bufio.NewReader(r)}
+
+	var header [12]byte
+	if _, err := io.ReadFull(cr, header[:]); err != nil {
+		return nil, fmt.Errorf("reading pack header: %w", err)
+	}
+	if string(header[:4]) != "PACK" || binary.BigEndian.Uint32(header[4:8]) != 2 {
+		return nil, errors.New("not a version 2 packfile")
+	}
+	count := binary.BigEndian.Uint32(header[8:])
+
+	// count comes from the server, so it only hints at the map's size.
+	objects := make(map[string]object, min(count, 1<<16))
+	byOffset := make(map[int64]string)
+	var deltas []delta
+	for range count {
+		offset := cr.n
+		typ, size, err := readObjectHeader(cr)
+		if err != nil {
+			return nil, err
+		}
+
+		d := delta{offset: offset}
+		switch typ {
+		case objOfsDelta:
+			back, err := readOffset(cr)
+			if err != nil {
+				return nil, err
+			}
+			d.baseOffset = offset - back
+		case objRefDelta:
+			var id [20]byte
+			if _, err := io.ReadFull(cr, id[:]); err != nil {
+				return nil, err
+			}
+			d.baseID = hex.EncodeToString(id[:])
+		}
+
+		data, err := inflate(cr, size)
+		if err != nil {
+			return nil, fmt.Errorf("inflating object at offset %d: %w", offset, err)
+		}
+		if typ == objOfsDelta || typ == objRefDelta {
+			d.data = data
+			deltas = append(deltas, d)
+			continue
+		}
+		id := objectID(typ, data)
+		objects[id] = object{typ: typ, data: data}
+		byOffset[offset] = id
+	}
+
+	// Bases usually precede their deltas, but a delta may also build on
+	// another delta; repeat until every delta is resolved.
+	for len(deltas) > 0 {
+		var pending []delta
+		for _, d := range deltas {
+			baseID := d.baseID
+			if baseID == "" {
+				baseID = byOffset[d.baseOffset]
+			}
+			base, ok := objects[baseID]
+			if !ok {
+				pending = append(pending, d)
+				continue
+			}
+			data, err := applyDelta(base.data, d.data)
+			if err != nil {
+				return nil, err
+			}
+			id := objectID(base.typ, data)
+			objects[id] = object{typ: base.typ, data: data}
+			byOffset[d.offset] = id
+		}
+		if len(pending) == len(deltas) {
+			return nil, fmt.Errorf("pack has %d deltas with missing bases", len(pending))
+		}
+		deltas = pending
+	}
+	return objects, nil
+}
+
+// readObjectHeader reads an object's type and size; the size is not needed
+// since the data is zlib-framed.
+func readObjectHeader(r io.ByteReader) (objType, uint64, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	typ := objType(c >> 4 & 7)
+	size := uint64(c & 15)
+	for shift := 4; c&0x80 != 0; shift += 7 {
+		if shift > 56 {
+			return 0, 0, errors.New("object size too large")
+		}
+		if c, err = r.ReadByte(); err != nil {
+			return 0, 0, err
+		}
+		size |= uint64(c&0x7f) << shift
+	}
+	return typ, size, nil
+}
+
+// readOffset reads the distance back to an ofs-delta's base.
+func readOffset(r io.ByteReader) (int64, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	offset := int64(c & 0x7f)
+	for c&0x80 != 0 {
+		if c, err = r.ReadByte(); err != nil {
+			return 0, err
+		}
+		offset = (offset+1)<<7 | int64(c&0x7f)
+	}
+	return offset, nil
+}
+
+// inflate decompresses one object of the size its header declares. Reading
+// stops one byte past that size, so a stream that inflates to more than the
+// header claims cannot exhaust memory.
+func inflate(r *countingReader, size uint64) ([]byte, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(io.LimitReader(zr, int64(size)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) != size {
+		return nil, fmt.Errorf("object inflates to %d bytes, but its header says %d", len(data), size)
+	}
+	return data, nil
+}
+
+// maxDeltaPrealloc caps the memory reserved for a delta's result up front;
+// larger results grow as they are produced.
+const maxDeltaPrealloc = 16 << 20
+
+// applyDelta rebuilds an object from its base and a delta, a list of
+// instructions that copy ranges of the base or insert new bytes.
+func applyDelta(base, d []byte) ([]byte, error) {
+	r := bytes.NewReader(d)
+	srcSize, err := binary.ReadUvarint(r)
+	if err != nil || srcSize != uint64(len(base)) {
+		return nil, errors.New("delta does not match its base")
+	}
+	dstSize, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, errors.New("malformed delta")
+	}
+	// Each instruction byte copies at most the whole base or inserts at most
+	// 127 bytes, which bounds what the delta can produce. The size comes
+	// from the server, so it is checked before anything is allocated.
+	if dstSize > uint64(r.Len())*uint64(max(len(base), 0x7f)) {
+		return nil, errors.New("delta claims more data than it can produce")
+	}
+
+	out := make([]byte, 0, min(dstSize, maxDeltaPrealloc))
+	for {
+		cmd, err := r.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if cmd&0x80 != 0 {
+			var offset, size uint64
+			for i := range 4 {
+				if cmd&(1<<i) != 0 {
+					b, _ := r.ReadByte()
+					offset |= uint64(b) << (8 * i)
+				}
+			}
+			for i := range 3 {
+				if cmd&(0x10<<i) != 0 {
+					b, _ := r.ReadByte()
+					size |= uint64(b) << (8 * i)
+				}
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if offset+size > uint64(len(base)) {
+				return nil, errors.New("delta copies past the end of its base")
+			}
+			if uint64(len(out))+size > dstSize {
+				return nil, errors.New("delta produced the wrong size")
+			}
+			out = append(out, base[offset:offset+size]...)
+			continue
+		}
+		if cmd == 0 {
+			return nil, errors.New("malformed delta")
+		}
+		if uint64(len(out))+uint64(cmd) > dstSize {
+			return nil, errors.New("delta produced the wrong size")
+		}
+		insert := make([]byte, cmd)
+		if _, err := io.ReadFull(r, insert); err != nil {
+			return nil, errors.New("malformed delta")
+		}
+		out = append(out, insert...)
+	}
+	if uint64(len(out)) != dstSize {
+		return nil, errors.New("delta produced the wrong size")
+	}
+	return out, nil
+}
+
+// commitTree returns the ID of a commit's root tree.
+func commitTree(data []byte) (string, error) {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	id, ok := bytes.CutPrefix(line, []byte("tree "))
+	if !ok || len(id) != 40 {
+		return "", errors.New("malformed commit")
+	}
+	return string(id), nil
+}
+
+// treeItem is one entry of a tree object.
+type treeItem struct {
+	mode string
+	name string
+	id   string
+}
+
+func parseTree(data []byte) ([]treeItem, error) {
+	var items []treeItem
+	for len(data) > 0 {
+		mode, rest, ok := bytes.Cut(data, []byte(" "))
+		if !ok {
+			return nil, errors.New("malformed tree")
+		}
+		name, rest, ok := bytes.Cut(rest, []byte{0})
+		if !ok || len(rest) < 20 {
+			return nil, errors.New("malformed tree")
+		}
+		items = append(items, treeItem{mode: string(mode), name: string(name), id: hex.EncodeToString(rest[:20])})
+		data = rest[20:]
+	}
+	return items, nil
+}
@@ -0,0 +1,139 @@
+// ABOUTME: Tests for packfile parsing.
+// ABOUTME: Verifies delta application, size bounds on server-declared sizes and object IDs of parsed trees and commits.
+
+package githttp
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestApplyDelta(t *testing.T) {
+	base := []byte("hello world")
+	// Sizes 11 and 11, copy 6 bytes from offset 0, insert "there".
+	d := []byte{11, 11, 0x80 | 0x10, 6, 5, 't', 'h', 'e', 'r', 'e'}
+
+	got, err := applyDelta(base, d)
+	if err != nil {
+		t.Fatalf("applyDelta() error = %v", err)
+	}
+	if string(got) != "hello there" {
+		t.Errorf("applyDelta() = %q, want %q", got, "hello there")
+	}
+
+	if _, err := applyDelta([]byte("short"), d); err == nil {
+		t.Error("applyDelta() with the wrong base error = nil, want error")
+	}
+
+	// A result size of 2^62 from a delta of a few bytes.
+	huge := []byte{11, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x40, 0x80 | 0x10, 6}
+	if _, err := applyDelta(base, huge); err == nil {
+		t.Error("applyDelta() with an impossible size error = nil, want error")
+	}
+	overrun := []byte{11, 3, 0x80 | 0x10, 6}
+	if _, err := applyDelta(base, overrun); err == nil {
+		t.Error("applyDelta() producing more than its size error = nil, want error")
+	}
+}
+
+// packObject encodes one undeltified object the way packfiles store it.
+func packObject(typ objType, data []byte) []byte {
+	var out bytes.Buffer
+	size := len(data)
+	c := byte(typ)<<4 | byte(size&15)
+	size >>= 4
+	for size > 0 {
+		out.WriteByte(c | 0x80)
+		c = byte(size & 0x7f)
+		size >>= 7
+	}
+	out.WriteByte(c)
+	zw := zlib.NewWriter(&out)
+	zw.Write(data)
+	zw.Close()
+	return out.Bytes()
+}
+
+func TestReadPack(t *testing.T) {
+	blob := []byte(strings.Repeat("docs\n", 10))
+
+	var pack bytes.Buffer
+	pack.WriteString("PACK")
+	binary.Write(&pack, binary.BigEndian, uint32(2))
+	binary.Write(&pack, binary.BigEndian, uint32(2))
+	blobOffset := pack.Len()
+	pack.Write(packObject(objBlob, blob))
+	// An ofs-delta appending one line to the blob.
+	deltaOffset := pack.Len()
+	pack.WriteByte(byte(objOfsDelta)<<4 | 10)
+	pack.WriteByte(byte(deltaOffset - blobOffset))
+	zw := zlib.NewWriter(&pack)
+	zw.Write([]byte{50, 55, 0x80 | 0x10, 50, 5, 'm', 'o', 'r', 'e', '\n'})
+	zw.Close()
+	pack.Write(make([]byte, 20))
+
+	objects, err := readPack(&pack)
+	if err != nil {
+		t.Fatalf("readPack() error = %v", err)
+	}
+	// IDs as computed by git hash-object.
+	if got := objects[objectID(objBlob, blob)]; !bytes.Equal(got.data, blob) {
+		t.Errorf("readPack() base = %q, want %q", got.data, blob)
+	}
+	want := string(blob) + "more\n"
+	if got := objects[objectID(objBlob, []byte(want))]; string(got.data) != want || got.typ != objBlob {
+		t.Errorf("readPack() delta = %v %q, want blob %q", got.typ, got.data, want)
+	}
+	if id := objectID(objBlob, []byte("hello\n")); id != "ce013625030ba8dba906f756967f9e9ca394464a" {
+		t.Errorf("objectID() = %s, want git's ID for \"hello\\n\"", id)
+	}
+}
+
+func TestParseTree(t *testing.T) {
+	id := bytes.Repeat([]byte{0xab}, 20)
+	data := append([]byte("100644 README.md\x00"), id...)
+	data = append(data, []byte("40000 docs\x00")...)
+	data = append(data, id...)
+
+	items, err := parseTree(data)
+	if err != nil {
+		t.Fatalf("parseTree() error = %v", err)
+	}
+	if len(items) != 2 || items[0].name != "README.md" || items[1].mode != "40000" || items[1].id != strings.Repeat("ab", 20) {
+		t.Errorf("parseTree() = %+v, want README.md and docs", items)
+	}
+	if _, err := parseTree([]byte("100644 broken")); err == nil {
+		t.Error("parseTree() of a truncated tree error = nil, want error")
+	}
+}
+
+func TestReadPack_ObjectLargerThanHeader(t *testing.T) {
+	// A header claiming 5 bytes in front of a stream inflating to a megabyte.
+	var pack bytes.Buffer
+	pack.WriteString("PACK")
+	binary.Write(&pack, binary.BigEndian, uint32(2))
+	binary.Write(&pack, binary.BigEndian, uint32(1))
+	pack.WriteByte(byte(objBlob)<<4 | 5)
+	zw := zlib.NewWriter(&pack)
+	zw.Write(make([]byte, 1<<20))
+	zw.Close()
+
+	if _, err := readPack(&pack); err == nil || !strings.Contains(err.Error(), "header says 5") {
+		t.Errorf("readPack() error = %v, want the object rejected for exceeding its header", err)
+	}
+}
+
+func TestReadPack_HugeCount(t *testing.T) {
+	// A header claiming four billion objects, followed by none.
+	var pack bytes.Buffer
+	pack.WriteString("PACK")
+	binary.Write(&pack, binary.BigEndian, uint32(2))
+	binary.Write(&pack, binary.BigEndian, uint32(1<<32-1))
+
+	if _, err := readPack(&pack); err == nil {
+		t.Error("readPack() of a truncated pack error = nil, want error")
+	}
+}
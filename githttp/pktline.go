@@ -0,0 +1,116 @@
+// ABOUTME: Encodes and decodes git pkt-lines and side-band streams.
+// ABOUTME: The framing every git protocol v2 request and response is built from.
+
+package githttp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Special packets that carry no payload.
+const (
+	flushPkt = "0000"
+	delimPkt = "0001"
+)
+
+type pktKind int
+
+const (
+	pktData pktKind = iota
+	pktFlush
+	pktDelim
+	pktResponseEnd
+)
+
+// maxPktLen is the largest packet git sends, length prefix included.
+const maxPktLen = 65520
+
+// pktLine frames s as one packet.
+func pktLine(s string) string {
+	return fmt.Sprintf("%04x%s", len(s)+4, s)
+}
+
+// pktReader reads packets from a stream.
+type pktReader struct {
+	r   io.Reader
+	buf [maxPktLen]byte
+}
+
+// next returns the next packet. The payload is only valid until the
+// following call.
+func (p *pktReader) next() (payload []byte, kind pktKind, err error) {
+	if _, err := io.ReadFull(p.r, p.buf[:4]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, 0, err
+	}
+	n, err := strconv.ParseUint(string(p.buf[:4]), 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("malformed pkt-line length %q", p.buf[:4])
+	}
+	switch n {
+	case 0:
+		return nil, pktFlush, nil
+	case 1:
+		return nil, pktDelim, nil
+	case 2:
+		return nil, pktResponseEnd, nil
+	}
+	if n < 4 || n > maxPktLen {
+		return nil, 0, fmt.Errorf("malformed pkt-line length %d", n)
+	}
+	if _, err := io.ReadFull(p.r, p.buf[4:n]); err != nil {
+		return nil, 0, err
+	}
+	return p.buf[4:n], pktData, nil
+}
+
+// nextLine is next for text packets, with the trailing newline removed.
+func (p *pktReader) nextLine() (string, pktKind, error) {
+	payload, kind, err := p.next()
+	return string(bytes.TrimSuffix(payload, []byte("\n"))), kind, err
+}
+
+// sidebandReader reads the pack data of a side-band stream: band 1 is data,
+// band 2 progress messages and band 3 a fatal error. It ends at a flush.
+type sidebandReader struct {
+	pkts *pktReader
+	data []byte
+	done bool
+}
+
+func (s *sidebandReader) Read(b []byte) (int, error) {
+	for len(s.data) == 0 {
+		if s.done {
+			return 0, io.EOF
+		}
+		payload, kind, err := s.pkts.next()
+		if err != nil {
+			return 0, err
+		}
+		if kind != pktData {
+			s.done = true
+			continue
+		}
+		if len(payload) == 0 {
+			continue
+		}
+		switch payload[0] {
+		case 1:
+			s.data = payload[1:]
+		case 2:
+			// Progress; not shown.
+		case 3:
+			return 0, fmt.Errorf("remote error: %s", bytes.TrimSpace(payload[1:]))
+		default:
+			return 0, fmt.Errorf("malformed side-band packet")
+		}
+	}
+	n := copy(b, s.data)
+	s.data = s.data[n:]
+	return n, nil
+}
@@ -0,0 +1,52 @@
+// ABOUTME: Client for the GitHub REST API.
+// ABOUTME: Looks up repository metadata such as the default branch.
+
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/bartriepe/my-docs/httpretry"
+)
+
+const apiBaseURL = "https://api.github.com"
+
+// Repository is the part of the GitHub repository resource my-docs uses.
+type Repository struct {
+	FullName      string `json:"full_name"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+func BuildRepoURL(repo string) string {
+	return fmt.Sprintf("%s/repos/%s", apiBaseURL, repo)
+}
+
+// DefaultBranch asks the GitHub API for repo's default branch.
+func DefaultBranch(repo string) (string, error) {
+	req, err := newRequest(BuildRepoURL(repo))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := httpretry.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError(resp, fmt.Sprintf("could not look up repository %s", repo))
+	}
+
+	var result Repository
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.DefaultBranch == "" {
+		return "", fmt.Errorf("GitHub API returned no default branch for %s", repo)
+	}
+	return result.DefaultBranch, nil
+}
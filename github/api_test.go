@@ -0,0 +1,14 @@
+// ABOUTME: Tests for the GitHub REST API client.
+// ABOUTME: Verifies API URL construction.
+
+package github
+
+import "testing"
+
+func TestBuildRepoURL(t *testing.T) {
+	got := BuildRepoURL("grafana/alloy")
+	want := "https://api.github.com/repos/grafana/alloy"
+	if got != want {
+		t.Errorf("BuildRepoURL() = %q, want %q", got, want)
+	}
+}
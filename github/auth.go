@@ -0,0 +1,82 @@
+// ABOUTME: Authenticates GitHub requests with a token from the environment or config.
+// ABOUTME: Builds requests and explains 404/401/403 responses in terms of access.
+
+package github
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// ErrNotFound is wrapped by errors for a 404 response.
+var ErrNotFound = errors.New("not found")
+
+// configToken is the token from the config file, used when neither
+// GITHUB_TOKEN nor GH_TOKEN is set.
+var configToken string
+
+// SetToken sets the token from the config file.
+func SetToken(token string) {
+	configToken = token
+}
+
+// authToken returns the token to send, preferring GITHUB_TOKEN, then GH_TOKEN,
+// then the config file. It is empty for anonymous access.
+func authToken() string {
+	for _, name := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		if token := os.Getenv(name); token != "" {
+			return token
+		}
+	}
+	return configToken
+}
+
+// newRequest builds a GET request for GitHub, authenticated when a token is
+// available.
+func newRequest(rawURL string) (*http.Request, error) {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	authorize(req)
+	return req, nil
+}
+
+// newPostRequest builds a POST request of body for GitHub, authenticated
+// when a token is available.
+func newPostRequest(rawURL string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequest("POST", rawURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	authorize(req)
+	return req, nil
+}
+
+func authorize(req *http.Request) {
+	req.Header.Set("User-Agent", "my-docs/1.0")
+	if token := authToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// statusError describes a failed response for what was requested. GitHub
+// answers 404 rather than 403 for private repos the caller cannot see, so a
+// 404 says which of the two it may be.
+func statusError(resp *http.Response, what string) error {
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		if authToken() == "" {
+			return fmt.Errorf("%s: %w (or private: set GITHUB_TOKEN or GH_TOKEN to read private repos)", what, ErrNotFound)
+		}
+		return fmt.Errorf("%s: %w (or not authorized: the token has no access to it)", what, ErrNotFound)
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%s: not authorized: GitHub rejected the token", what)
+	case http.StatusForbidden:
+		return fmt.Errorf("%s: not authorized (HTTP 403)", what)
+	}
+	return fmt.Errorf("%s: HTTP %d", what, resp.StatusCode)
+}
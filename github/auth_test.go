@@ -0,0 +1,80 @@
+// ABOUTME: Tests for GitHub authentication.
+// ABOUTME: Verifies token precedence, request headers and access error messages.
+
+package github
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAuthToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		github string
+		gh     string
+		config string
+		want   string
+	}{
+		{"anonymous", "", "", "", ""},
+		{"config", "", "", "cfg", "cfg"},
+		{"GH_TOKEN over config", "", "gh", "cfg", "gh"},
+		{"GITHUB_TOKEN first", "github", "gh", "cfg", "github"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_TOKEN", tt.github)
+			t.Setenv("GH_TOKEN", tt.gh)
+			SetToken(tt.config)
+			defer SetToken("")
+
+			if got := authToken(); got != tt.want {
+				t.Errorf("authToken() = %q, want %q", got, tt.want)
+			}
+
+			req, err := newRequest("https://api.github.com/repos/a/b")
+			if err != nil {
+				t.Fatalf("newRequest() error = %v", err)
+			}
+			want := ""
+			if tt.want != "" {
+				want = "Bearer " + tt.want
+			}
+			if got := req.Header.Get("Authorization"); got != want {
+				t.Errorf("Authorization = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		token  string
+		want   string
+	}{
+		{"404 anonymous", http.StatusNotFound, "", "not found (or private: set GITHUB_TOKEN"},
+		{"404 with token", http.StatusNotFound, "tok", "not found (or not authorized: the token has no access"},
+		{"401", http.StatusUnauthorized, "tok", "not authorized: GitHub rejected the token"},
+		{"500", http.StatusInternalServerError, "", "HTTP 500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_TOKEN", tt.token)
+			t.Setenv("GH_TOKEN", "")
+
+			err := statusError(&http.Response{StatusCode: tt.status}, "a/b")
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("statusError() = %q, want it to contain %q", err, tt.want)
+			}
+			if got := errors.Is(err, ErrNotFound); got != (tt.status == http.StatusNotFound) {
+				t.Errorf("errors.Is(statusError(), ErrNotFound) = %v for HTTP %d", got, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// ABOUTME: Reads commit history through the GitHub commits API.
+// ABOUTME: Lists the commits touching a path and fetches a commit's per-file patches.
+
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/bartriepe/my-docs/httpretry"
+)
+
+// commitsPerPage is the largest page the commits API serves.
+const commitsPerPage = 100
+
+// commitFilesPerPage is how many changed files the commit API lists per
+// page, and maxCommitFilePages how many pages it serves: 3000 files in all.
+const (
+	commitFilesPerPage = 300
+	maxCommitFilePages = 10
+)
+
+// Commit is the part of the GitHub commit resource my-docs uses.
+type Commit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Author struct {
+			Name string    `json:"name"`
+			Date time.Time `json:"date"`
+		} `json:"author"`
+		Message string `json:"message"`
+	} `json:"commit"`
+	// Files is only filled in by FetchCommit.
+	Files []CommitFile `json:"files"`
+}
+
+// CommitFile is one file a commit changed.
+type CommitFile struct {
+	Filename         string `json:"filename"`
+	PreviousFilename string `json:"previous_filename"`
+	// Status is "added", "modified", "removed", "renamed" and so on.
+	Status string `json:"status"`
+	// Patch is the file's unified diff hunks, empty for binary files and
+	// diffs too large for the API to include.
+	Patch string `json:"patch"`
+}
+
+// LogOptions narrows a commit listing.
+type LogOptions struct {
+	// Path limits the listing to commits touching a file or directory.
+	Path string
+	// Ref is the branch, tag or commit to list from; empty for the default
+	// branch.
+	Ref string
+	// Since leaves out commits older than it, unless it is zero.
+	Since time.Time
+}
+
+func BuildCommitsURL(repo string, opts LogOptions, perPage, page int) string {
+	params := url.Values{}
+	if opts.Path != "" {
+		params.Set("path", opts.Path)
+	}
+	if opts.Ref != "" {
+		params.Set("sha", opts.Ref)
+	}
+	if !opts.Since.IsZero() {
+		params.Set("since", opts.Since.UTC().Format(time.RFC3339))
+	}
+	params.Set("per_page", strconv.Itoa(perPage))
+	params.Set("page", strconv.Itoa(page))
+	return fmt.Sprintf("%s/repos/%s/commits?%s", apiBaseURL, repo, params.Encode())
+}
+
+func BuildCommitURL(repo, sha string, page int) string {
+	return fmt.Sprintf("%s/repos/%s/commits/%s?page=%d", apiBaseURL, repo, url.PathEscape(sha), page)
+}
+
+// Commits returns up to limit commits of repo matching opts, newest first.
+func Commits(repo string, opts LogOptions, limit int) ([]Commit, error) {
+	perPage := min(limit, commitsPerPage)
+	var commits []Commit
+	for page := 1; len(commits) < limit; page++ {
+		var batch []Commit
+		if err := getJSON(BuildCommitsURL(repo, opts, perPage, page), fmt.Sprintf("could not list commits of %s", repo), &batch); err != nil {
+			return nil, err
+		}
+		commits = append(commits, batch...)
+		if len(batch) < perPage {
+			break
+		}
+	}
+	return commits[:min(limit, len(commits))], nil
+}
+
+// FetchCommit returns a commit with the files it changed. The API lists 300
+// files a page; further pages are only read for commits that fill the first,
+// up to the 3000 files the API serves.
+func FetchCommit(repo, sha string) (*Commit, error) {
+	what := fmt.Sprintf("could not fetch commit %s of %s", sha, repo)
+	var c Commit
+	if err := getJSON(BuildCommitURL(repo, sha, 1), what, &c); err != nil {
+		return nil, err
+	}
+	for page, n := 2, len(c.Files); n == commitFilesPerPage && page <= maxCommitFilePages; page++ {
+		var next Commit
+		if err := getJSON(BuildCommitURL(repo, sha, page), what, &next); err != nil {
+			return nil, err
+		}
+		c.Files = append(c.Files, next.Files...)
+		n = len(next.Files)
+	}
+	return &c, nil
+}
+
+// getJSON fetches an API URL and decodes its body into v. what describes the
+// request in errors.
+func getJSON(rawURL, what string, v any) error {
+	req, err := newRequest(rawURL)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := httpretry.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp, what)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,134 @@
+// ABOUTME: Tests for the GitHub commits client.
+// ABOUTME: Verifies commit listing, commit URL construction and paging through history and file lists.
+
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildCommitsURL(t *testing.T) {
+	tests := []struct {
+		name string
+		opts LogOptions
+		want string
+	}{
+		{
+			name: "default branch",
+			want: "https://api.github.com/repos/grafana/alloy/commits?page=1&per_page=10",
+		},
+		{
+			name: "path ref and since",
+			opts: LogOptions{Path: "docs/my file.md", Ref: "release/1.4", Since: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+			want: "https://api.github.com/repos/grafana/alloy/commits?page=1&path=docs%2Fmy+file.md&per_page=10&sha=release%2F1.4&since=2024-05-01T00%3A00%3A00Z",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BuildCommitsURL("grafana/alloy", tt.opts, 10, 1); got != tt.want {
+				t.Errorf("BuildCommitsURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildCommitURL(t *testing.T) {
+	got := BuildCommitURL("grafana/alloy", "4b825dc642cb6eb9a060e54bf8d69288fbee4904", 2)
+	want := "https://api.github.com/repos/grafana/alloy/commits/4b825dc642cb6eb9a060e54bf8d69288fbee4904?page=2"
+	if got != want {
+		t.Errorf("BuildCommitURL() = %q, want %q", got, want)
+	}
+}
+
+// commitFiles is a commit resource listing files from to to, exclusive.
+func commitFiles(from, to int) string {
+	var files []string
+	for n := from; n < to; n++ {
+		files = append(files, fmt.Sprintf(`{"filename": "f%d.go"}`, n))
+	}
+	return fmt.Sprintf(`{"sha": "abc", "files": [%s]}`, strings.Join(files, ","))
+}
+
+func TestFetchCommit_Pages(t *testing.T) {
+	// 305 files: a full first page of 300, then 5.
+	var pages []string
+	stubAPI(t, func(req *http.Request) string {
+		page := req.URL.Query().Get("page")
+		pages = append(pages, page)
+		if page == "1" {
+			return commitFiles(0, 300)
+		}
+		return commitFiles(300, 305)
+	})
+
+	c, err := FetchCommit("grafana/alloy", "abc")
+	if err != nil {
+		t.Fatalf("FetchCommit() error = %v", err)
+	}
+	if len(c.Files) != 305 || c.Files[0].Filename != "f0.go" || c.Files[304].Filename != "f304.go" {
+		t.Errorf("FetchCommit() = %d files, want f0.go to f304.go", len(c.Files))
+	}
+	if got := strings.Join(pages, " "); got != "1 2" {
+		t.Errorf("FetchCommit() fetched pages %q, want %q", got, "1 2")
+	}
+}
+
+func TestFetchCommit_StopsAtMaxPages(t *testing.T) {
+	requests := 0
+	stubAPI(t, func(req *http.Request) string {
+		requests++
+		return commitFiles(0, commitFilesPerPage)
+	})
+
+	c, err := FetchCommit("grafana/alloy", "abc")
+	if err != nil {
+		t.Fatalf("FetchCommit() error = %v", err)
+	}
+	if requests != maxCommitFilePages || len(c.Files) != 3000 {
+		t.Errorf("FetchCommit() = %d files in %d requests, want 3000 in %d", len(c.Files), requests, maxCommitFilePages)
+	}
+}
+
+func TestCommits_Pages(t *testing.T) {
+	// The history holds 120 commits, served 100 to a page.
+	var pages []string
+	stubAPI(t, func(req *http.Request) string {
+		q := req.URL.Query()
+		pages = append(pages, q.Get("page")+"/"+q.Get("per_page"))
+		var page, perPage int
+		fmt.Sscan(q.Get("page"), &page)
+		fmt.Sscan(q.Get("per_page"), &perPage)
+		var commits []string
+		for n := (page-1)*perPage + 1; n <= min(page*perPage, 120); n++ {
+			commits = append(commits, fmt.Sprintf(`{"sha": "%d"}`, n))
+		}
+		return "[" + strings.Join(commits, ",") + "]"
+	})
+
+	tests := []struct {
+		limit int
+		want  int
+		pages string
+	}{
+		{20, 20, "1/20"},
+		{110, 110, "1/100 2/100"},
+		{500, 120, "1/100 2/100"},
+	}
+	for _, tt := range tests {
+		pages = nil
+		commits, err := Commits("grafana/alloy", LogOptions{}, tt.limit)
+		if err != nil {
+			t.Fatalf("Commits(limit %d) error = %v", tt.limit, err)
+		}
+		if len(commits) != tt.want || commits[len(commits)-1].SHA != fmt.Sprint(tt.want) {
+			t.Errorf("Commits(limit %d) = %d commits, want %d", tt.limit, len(commits), tt.want)
+		}
+		if got := strings.Join(pages, " "); got != tt.pages {
+			t.Errorf("Commits(limit %d) fetched pages %q, want %q", tt.limit, got, tt.pages)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// ABOUTME: Searches and reads GitHub Discussions through the GraphQL API.
+// ABOUTME: Discussions have no REST search, and GraphQL needs a token even for public repos.
+
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bartriepe/my-docs/httpretry"
+)
+
+const graphQLURL = apiBaseURL + "/graphql"
+
+// ErrNoToken is returned for discussions when no token is set.
+var ErrNoToken = errors.New("GitHub's GraphQL API, which serves discussions, needs a token: set GITHUB_TOKEN or GH_TOKEN, or \"github_token\" in the config file")
+
+const searchDiscussionsQuery = `query($q: String!, $first: Int!, $after: String) {
+  search(query: $q, type: DISCUSSION, first: $first, after: $after) {
+    discussionCount
+    pageInfo { hasNextPage endCursor }
+    nodes { ... on Discussion { ` + discussionFields + ` } }
+  }
+}`
+
+const discussionQuery = `query($owner: String!, $name: String!, $number: Int!, $after: String) {
+  repository(owner: $owner, name: $name) {
+    discussion(number: $number) {
+      ` + discussionFields + `
+      comments(first: 100, after: $after) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          body createdAt author { login }
+          replies(first: 100) { nodes { body createdAt author { login } } }
+        }
+      }
+    }
+  }
+}`
+
+const discussionFields = `number title body url closed createdAt author { login } comments { totalCount }`
+
+// discussion is a Discussion as the queries above return it.
+type discussion struct {
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	URL       string    `json:"url"`
+	Closed    bool      `json:"closed"`
+	CreatedAt time.Time `json:"createdAt"`
+	Author    *author   `json:"author"`
+	Comments  struct {
+		TotalCount int                 `json:"totalCount"`
+		PageInfo   pageInfo            `json:"pageInfo"`
+		Nodes      []discussionComment `json:"nodes"`
+	} `json:"comments"`
+}
+
+type discussionComment struct {
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+	Author    *author   `json:"author"`
+	Replies   struct {
+		Nodes []discussionComment `json:"nodes"`
+	} `json:"replies"`
+}
+
+// author is null for deleted accounts.
+type author struct {
+	Login string `json:"login"`
+}
+
+type pageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+func (a *author) login() string {
+	if a == nil {
+		return "ghost"
+	}
+	return a.Login
+}
+
+// issue converts d to an Issue with Discussion set.
+func (d discussion) issue() Issue {
+	is := Issue{
+		Number:     d.Number,
+		Title:      d.Title,
+		State:      "open",
+		Body:       d.Body,
+		HTMLURL:    d.URL,
+		Comments:   d.Comments.TotalCount,
+		CreatedAt:  d.CreatedAt,
+		Discussion: true,
+	}
+	if d.Closed {
+		is.State = "closed"
+	}
+	is.User.Login = d.Author.login()
+	return is
+}
+
+// thread flattens c and its replies into Comments, oldest first.
+func (c discussionComment) thread() []Comment {
+	comments := []Comment{c.comment(false)}
+	for _, r := range c.Replies.Nodes {
+		comments = append(comments, r.comment(true))
+	}
+	return comments
+}
+
+func (c discussionComment) comment(reply bool) Comment {
+	out := Comment{Body: c.Body, CreatedAt: c.CreatedAt, Reply: reply}
+	out.User.Login = c.Author.login()
+	return out
+}
+
+// SearchDiscussions returns the best matching discussions for q in repo, up
+// to limit and at most MaxSearchResults, and how many match in total.
+func SearchDiscussions(repo string, q IssueQuery, limit int) ([]Issue, int, error) {
+	limit = min(limit, MaxSearchResults)
+	what := fmt.Sprintf("could not search discussions of %s", repo)
+	var issues []Issue
+	total := 0
+	var after *string
+	for len(issues) < limit {
+		var result struct {
+			Search struct {
+				DiscussionCount int          `json:"discussionCount"`
+				PageInfo        pageInfo     `json:"pageInfo"`
+				Nodes           []discussion `json:"nodes"`
+			} `json:"search"`
+		}
+		vars := map[string]any{"q": q.SearchTerms(repo), "first": min(limit-len(issues), issuesPerPage), "after": after}
+		if err := graphQL(searchDiscussionsQuery, vars, what, &result); err != nil {
+			return nil, 0, err
+		}
+		for _, d := range result.Search.Nodes {
+			issues = append(issues, d.issue())
+		}
+		total = result.Search.DiscussionCount
+		if !result.Search.PageInfo.HasNextPage {
+			break
+		}
+		after = &result.Search.PageInfo.EndCursor
+	}
+	return issues, total, nil
+}
+
+// FetchDiscussion returns a discussion and its comments, each followed by its
+// replies. Only the first 100 replies to a comment are returned.
+func FetchDiscussion(repo string, number int) (*Issue, []Comment, error) {
+	owner, name, _ := strings.Cut(repo, "/")
+	what := fmt.Sprintf("could not fetch discussion #%d of %s", number, repo)
+	var is *Issue
+	var comments []Comment
+	var after *string
+	for {
+		var result struct {
+			Repository struct {
+				Discussion *discussion `json:"discussion"`
+			} `json:"repository"`
+		}
+		vars := map[string]any{"owner": owner, "name": name, "number": number, "after": after}
+		if err := graphQL(discussionQuery, vars, what, &result); err != nil {
+			return nil, nil, err
+		}
+		d := result.Repository.Discussion
+		if d == nil {
+			return nil, nil, fmt.Errorf("%s: %w", what, ErrNotFound)
+		}
+		if is == nil {
+			issue := d.issue()
+			is = &issue
+		}
+		for _, c := range d.Comments.Nodes {
+			comments = append(comments, c.thread()...)
+		}
+		if !d.Comments.PageInfo.HasNextPage {
+			return is, comments, nil
+		}
+		after = &d.Comments.PageInfo.EndCursor
+	}
+}
+
+// graphQL runs query with vars and decodes its data into v.
+func graphQL(query string, vars map[string]any, what string, v any) error {
+	if authToken() == "" {
+		return fmt.Errorf("%s: %w", what, ErrNoToken)
+	}
+	body, err := json.Marshal(map[string]any{"query": query, "variables": vars})
+	if err != nil {
+		return err
+	}
+	req, err := newPostRequest(graphQLURL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpretry.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp, what)
+	}
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		if result.Errors[0].Type == "NOT_FOUND" {
+			return fmt.Errorf("%s: %w (%s)", what, ErrNotFound, result.Errors[0].Message)
+		}
+		return fmt.Errorf("%s: %s", what, result.Errors[0].Message)
+	}
+	return json.Unmarshal(result.Data, v)
+}
@@ -0,0 +1,63 @@
+// ABOUTME: Tests for the GitHub Discussions client.
+// ABOUTME: Verifies discussions and their replies decode into issues and comments.
+
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestDiscussionIssue(t *testing.T) {
+	const data = `{"number": 7, "title": "How do I reload?", "body": "Is there a way?",
+		"url": "https://github.com/owner/repo/discussions/7", "closed": true,
+		"createdAt": "2024-05-01T10:00:00Z", "author": null, "comments": {"totalCount": 3}}`
+	var d discussion
+	if err := json.Unmarshal([]byte(data), &d); err != nil {
+		t.Fatal(err)
+	}
+	is := d.issue()
+	if !is.Discussion || is.IsPR() || is.Number != 7 || is.State != "closed" || is.Comments != 3 {
+		t.Errorf("issue() = %+v, want closed discussion #7 with 3 comments", is)
+	}
+	if is.User.Login != "ghost" {
+		t.Errorf("issue() author = %q, want %q for a deleted account", is.User.Login, "ghost")
+	}
+}
+
+func TestDiscussionCommentThread(t *testing.T) {
+	const data = `{"body": "Send SIGHUP.", "author": {"login": "bob"}, "replies": {"nodes": [
+		{"body": "Thanks!", "author": {"login": "alice"}},
+		{"body": "Or restart it.", "author": {"login": "carol"}}]}}`
+	var c discussionComment
+	if err := json.Unmarshal([]byte(data), &c); err != nil {
+		t.Fatal(err)
+	}
+	got := c.thread()
+	want := []struct {
+		login string
+		reply bool
+	}{{"bob", false}, {"alice", true}, {"carol", true}}
+	if len(got) != len(want) {
+		t.Fatalf("thread() = %d comments, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].User.Login != w.login || got[i].Reply != w.reply {
+			t.Errorf("thread()[%d] = %s (reply %v), want %s (reply %v)", i, got[i].User.Login, got[i].Reply, w.login, w.reply)
+		}
+	}
+}
+
+func TestGraphQLNeedsToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	SetToken("")
+
+	if _, _, err := SearchDiscussions("owner/repo", IssueQuery{Text: "reload"}, 10); !errors.Is(err, ErrNoToken) {
+		t.Errorf("SearchDiscussions() error = %v, want ErrNoToken", err)
+	}
+	if _, _, err := FetchDiscussion("owner/repo", 7); !errors.Is(err, ErrNoToken) {
+		t.Errorf("FetchDiscussion() error = %v, want ErrNoToken", err)
+	}
+}
@@ -0,0 +1,142 @@
+// ABOUTME: Searches and reads issues and pull requests through the GitHub REST API.
+// ABOUTME: Pages through the issue search API for queries and the issues API for full threads.
+
+package github
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// issuesPerPage is the largest page the search and comments APIs serve.
+const issuesPerPage = 100
+
+// MaxSearchResults is how many results GitHub's searches return at most,
+// however many match.
+const MaxSearchResults = 1000
+
+// Issue is the part of the GitHub issue resource my-docs uses. Pull requests
+// are issues too, with PullRequest set, and discussions are read into one
+// with Discussion set.
+type Issue struct {
+	Number   int    `json:"number"`
+	Title    string `json:"title"`
+	State    string `json:"state"`
+	Body     string `json:"body"`
+	HTMLURL  string `json:"html_url"`
+	Comments int    `json:"comments"`
+	User     struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	CreatedAt   time.Time `json:"created_at"`
+	PullRequest *struct{} `json:"pull_request"`
+	Discussion  bool      `json:"-"`
+}
+
+// IsPR reports whether the issue is a pull request.
+func (i Issue) IsPR() bool {
+	return i.PullRequest != nil
+}
+
+// Comment is one comment on an issue, pull request or discussion.
+type Comment struct {
+	Body string `json:"body"`
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+	// Reply is set for a reply to the discussion comment before it.
+	Reply bool `json:"-"`
+}
+
+// IssueQuery is a search for issues and pull requests in one repo.
+type IssueQuery struct {
+	Text string
+	// State is "open", "closed" or empty for both.
+	State string
+	// Type is "issue", "pr", "discussion" or empty for issues and pull
+	// requests.
+	Type string
+}
+
+// SearchTerms turns q into the search syntax for repo, e.g.
+// "timeout repo:grafana/alloy is:open is:issue". Discussions are searched
+// separately, so their type is not a term.
+func (q IssueQuery) SearchTerms(repo string) string {
+	terms := q.Text + " repo:" + repo
+	if q.State != "" {
+		terms += " is:" + q.State
+	}
+	if q.Type != "" && q.Type != "discussion" {
+		terms += " is:" + q.Type
+	}
+	return terms
+}
+
+func BuildIssueSearchURL(repo string, q IssueQuery, perPage, page int) string {
+	params := url.Values{}
+	params.Set("q", q.SearchTerms(repo))
+	params.Set("per_page", strconv.Itoa(perPage))
+	params.Set("page", strconv.Itoa(page))
+	return fmt.Sprintf("%s/search/issues?%s", apiBaseURL, params.Encode())
+}
+
+func BuildIssueURL(repo string, number int) string {
+	return fmt.Sprintf("%s/repos/%s/issues/%d", apiBaseURL, repo, number)
+}
+
+func BuildCommentsURL(repo string, number, page int) string {
+	return fmt.Sprintf("%s/repos/%s/issues/%d/comments?per_page=%d&page=%d", apiBaseURL, repo, number, issuesPerPage, page)
+}
+
+// SearchIssues returns the best matches for q in repo, up to limit and at
+// most MaxSearchResults, and how many issues and pull requests match in
+// total. Discussions are searched with SearchDiscussions.
+func SearchIssues(repo string, q IssueQuery, limit int) ([]Issue, int, error) {
+	limit = min(limit, MaxSearchResults)
+	perPage := min(limit, issuesPerPage)
+	var issues []Issue
+	total := 0
+	for page := 1; len(issues) < limit; page++ {
+		var result struct {
+			TotalCount int     `json:"total_count"`
+			Items      []Issue `json:"items"`
+		}
+		if err := getJSON(BuildIssueSearchURL(repo, q, perPage, page), fmt.Sprintf("could not search issues of %s", repo), &result); err != nil {
+			return nil, 0, err
+		}
+		issues = append(issues, result.Items...)
+		total = result.TotalCount
+		if len(result.Items) < perPage {
+			break
+		}
+	}
+	return issues[:min(len(issues), limit)], total, nil
+}
+
+// FetchIssue returns one issue or pull request.
+func FetchIssue(repo string, number int) (*Issue, error) {
+	var issue Issue
+	if err := getJSON(BuildIssueURL(repo, number), fmt.Sprintf("could not fetch #%d of %s", number, repo), &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+// FetchComments returns every comment on an issue or pull request, oldest
+// first. Review comments on the lines of a pull request are not included.
+func FetchComments(repo string, number int) ([]Comment, error) {
+	var comments []Comment
+	for page := 1; ; page++ {
+		var batch []Comment
+		if err := getJSON(BuildCommentsURL(repo, number, page), fmt.Sprintf("could not fetch the comments on #%d of %s", number, repo), &batch); err != nil {
+			return nil, err
+		}
+		comments = append(comments, batch...)
+		if len(batch) < issuesPerPage {
+			return comments, nil
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// ABOUTME: Tests for the GitHub issues client.
+// ABOUTME: Verifies search terms, issue URL construction and paging through search results.
+
+package github
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bartriepe/my-docs/httpretry"
+)
+
+func TestIssueQuerySearchTerms(t *testing.T) {
+	tests := []struct {
+		q    IssueQuery
+		want string
+	}{
+		{IssueQuery{Text: "timeout"}, "timeout repo:grafana/alloy"},
+		{IssueQuery{Text: "timeout", State: "open", Type: "pr"}, "timeout repo:grafana/alloy is:open is:pr"},
+		{IssueQuery{Text: "timeout", State: "closed", Type: "discussion"}, "timeout repo:grafana/alloy is:closed"},
+	}
+	for _, tt := range tests {
+		if got := tt.q.SearchTerms("grafana/alloy"); got != tt.want {
+			t.Errorf("SearchTerms() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestBuildIssueURLs(t *testing.T) {
+	tests := []struct {
+		name, got, want string
+	}{
+		{"search", BuildIssueSearchURL("grafana/alloy", IssueQuery{Text: "otel timeout", State: "closed"}, 10, 2), "https://api.github.com/search/issues?page=2&per_page=10&q=otel+timeout+repo%3Agrafana%2Falloy+is%3Aclosed"},
+		{"issue", BuildIssueURL("grafana/alloy", 123), "https://api.github.com/repos/grafana/alloy/issues/123"},
+		{"comments", BuildCommentsURL("grafana/alloy", 123, 2), "https://api.github.com/repos/grafana/alloy/issues/123/comments?per_page=100&page=2"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s URL = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// stubAPI answers every API request with the JSON body serve returns, until
+// the test ends.
+func stubAPI(t *testing.T, serve func(req *http.Request) string) {
+	client := httpretry.Client
+	t.Cleanup(func() { httpretry.Client = client })
+	httpretry.Client = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(serve(req)))}, nil
+	})}
+}
+
+func TestSearchIssues_Pages(t *testing.T) {
+	// 250 issues match; the search serves them 100 to a page.
+	var pages []string
+	stubAPI(t, func(req *http.Request) string {
+		q := req.URL.Query()
+		pages = append(pages, q.Get("page")+"/"+q.Get("per_page"))
+		var page, perPage int
+		fmt.Sscan(q.Get("page"), &page)
+		fmt.Sscan(q.Get("per_page"), &perPage)
+		var items []string
+		for n := (page-1)*perPage + 1; n <= min(page*perPage, 250); n++ {
+			items = append(items, fmt.Sprintf(`{"number": %d}`, n))
+		}
+		return fmt.Sprintf(`{"total_count": 250, "items": [%s]}`, strings.Join(items, ","))
+	})
+
+	tests := []struct {
+		limit int
+		want  int
+		pages string
+	}{
+		{10, 10, "1/10"},
+		{150, 150, "1/100 2/100"},
+		{1000, 250, "1/100 2/100 3/100"},
+	}
+	for _, tt := range tests {
+		pages = nil
+		issues, total, err := SearchIssues("owner/repo", IssueQuery{Text: "crash"}, tt.limit)
+		if err != nil {
+			t.Fatalf("SearchIssues(limit %d) error = %v", tt.limit, err)
+		}
+		if len(issues) != tt.want || total != 250 || issues[len(issues)-1].Number != tt.want {
+			t.Errorf("SearchIssues(limit %d) = %d issues of %d, want %d of 250", tt.limit, len(issues), total, tt.want)
+		}
+		if got := strings.Join(pages, " "); got != tt.pages {
+			t.Errorf("SearchIssues(limit %d) fetched pages %q, want %q", tt.limit, got, tt.pages)
+		}
+	}
+}
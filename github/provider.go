@@ -0,0 +1,38 @@
+// ABOUTME: Exposes the github package as a forge.Provider.
+// ABOUTME: Lets commands read GitHub repos through the same interface as other hosts.
+
+package github
+
+import (
+	"io"
+
+	"github.com/bartriepe/my-docs/forge"
+)
+
+// Provider reads repositories on github.com.
+type Provider struct{}
+
+var (
+	_ forge.Provider = Provider{}
+	_ forge.Releaser = Provider{}
+)
+
+func (Provider) DefaultBranch(repo string) (string, error) {
+	return DefaultBranch(repo)
+}
+
+func (Provider) OpenFile(repo, ref, path string) (io.ReadCloser, error) {
+	return OpenFileAt(repo, ref, path)
+}
+
+func (Provider) Tree(repo, ref string) (*forge.Tree, error) {
+	return FetchTree(repo, ref)
+}
+
+func (Provider) OpenTarball(repo, ref string) (io.ReadCloser, error) {
+	return OpenTarball(repo, ref)
+}
+
+func (Provider) ReleasePage(repo string, page int) ([]forge.Release, bool, error) {
+	return ReleasePage(repo, page)
+}
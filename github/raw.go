@@ -1,5 +1,5 @@
 // ABOUTME: Fetches raw files from GitHub via raw.githubusercontent.com.
-// ABOUTME: Handles URL construction and branch fallback (main -> master).
+// ABOUTME: Handles URL construction and reads files at a ref or the default branch.
 
 package github
 
@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+
+	"github.com/bartriepe/my-docs/httpretry"
 )
 
 const rawBaseURL = "https://raw.githubusercontent.com"
@@ -19,35 +21,47 @@ func BuildRawURL(repo, branch, path string) string {
 	return fmt.Sprintf("%s/%s/%s/%s", rawBaseURL, repo, branch, encodedPath)
 }
 
+// FetchFile fetches path from repo's default branch. If the branch cannot be
+// looked up, it falls back to HEAD, which raw.githubusercontent.com resolves
+// to the default branch itself.
 func FetchFile(repo, path string) (string, error) {
-	branches := []string{"main", "master"}
-
-	var lastErr error
-	for _, branch := range branches {
-		rawURL := BuildRawURL(repo, branch, path)
-
-		resp, err := http.Get(rawURL)
-		if err != nil {
-			lastErr = err
-			continue
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode == http.StatusNotFound {
-			lastErr = fmt.Errorf("not found on branch %s", branch)
-			continue
-		}
-		if resp.StatusCode != http.StatusOK {
-			lastErr = fmt.Errorf("HTTP %d", resp.StatusCode)
-			continue
-		}
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return "", err
-		}
-		return string(body), nil
+	branch, err := DefaultBranch(repo)
+	if err != nil {
+		branch = "HEAD"
+	}
+	return FetchFileAt(repo, branch, path)
+}
+
+// FetchFileAt fetches path from repo at a specific branch, tag or commit.
+func FetchFileAt(repo, ref, path string) (string, error) {
+	body, err := OpenFileAt(repo, ref, path)
+	if err != nil {
+		return "", err
 	}
+	defer body.Close()
 
-	return "", fmt.Errorf("could not fetch %s/%s: %v", repo, path, lastErr)
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// OpenFileAt opens path from repo at a specific branch, tag or commit for
+// streaming. The caller must close the returned body.
+func OpenFileAt(repo, ref, path string) (io.ReadCloser, error) {
+	req, err := newRequest(BuildRawURL(repo, ref, path))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpretry.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, statusError(resp, fmt.Sprintf("could not fetch %s/%s at %s", repo, path, ref))
+	}
+	return resp.Body, nil
 }
@@ -0,0 +1,52 @@
+// ABOUTME: Lists repository releases through the GitHub releases API.
+// ABOUTME: Reads releases a page at a time, newest first, leaving out drafts.
+
+package github
+
+import (
+	"fmt"
+
+	"github.com/bartriepe/my-docs/forge"
+)
+
+// releasesPerPage is the largest page the releases API serves.
+const releasesPerPage = 100
+
+func BuildReleasesURL(repo string, page int) string {
+	return fmt.Sprintf("%s/repos/%s/releases?per_page=%d&page=%d", apiBaseURL, repo, releasesPerPage, page)
+}
+
+// ReleasePage returns one page of repo's releases, newest first, and
+// whether more may follow.
+func ReleasePage(repo string, page int) ([]forge.Release, bool, error) {
+	var batch []release
+	if err := getJSON(BuildReleasesURL(repo, page), fmt.Sprintf("could not list releases of %s", repo), &batch); err != nil {
+		return nil, false, err
+	}
+	var releases []forge.Release
+	for _, r := range batch {
+		if r.Draft {
+			continue
+		}
+		releases = append(releases, forge.Release{
+			Tag:        r.TagName,
+			Name:       r.Name,
+			Body:       r.Body,
+			Prerelease: r.Prerelease,
+			Published:  r.PublishedAt,
+			URL:        r.HTMLURL,
+		})
+	}
+	return releases, len(batch) == releasesPerPage, nil
+}
+
+// release is the part of the GitHub release resource my-docs uses.
+type release struct {
+	TagName     string `json:"tag_name"`
+	Name        string `json:"name"`
+	Body        string `json:"body"`
+	Draft       bool   `json:"draft"`
+	Prerelease  bool   `json:"prerelease"`
+	PublishedAt string `json:"published_at"`
+	HTMLURL     string `json:"html_url"`
+}
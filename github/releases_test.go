@@ -0,0 +1,14 @@
+// ABOUTME: Tests for the GitHub releases client.
+// ABOUTME: Verifies releases URL construction.
+
+package github
+
+import "testing"
+
+func TestBuildReleasesURL(t *testing.T) {
+	got := BuildReleasesURL("grafana/alloy", 2)
+	want := "https://api.github.com/repos/grafana/alloy/releases?per_page=100&page=2"
+	if got != want {
+		t.Errorf("BuildReleasesURL() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,37 @@
+// ABOUTME: Downloads repository snapshots as tarballs from codeload.github.com.
+// ABOUTME: Used to search repos locally when grep.app does not index them.
+
+package github
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/bartriepe/my-docs/httpretry"
+)
+
+const codeloadBaseURL = "https://codeload.github.com"
+
+func BuildTarballURL(repo, ref string) string {
+	return fmt.Sprintf("%s/%s/tar.gz/%s", codeloadBaseURL, repo, ref)
+}
+
+// OpenTarball opens the gzipped tarball of repo at a branch, tag or commit
+// for streaming. The caller must close the returned body.
+func OpenTarball(repo, ref string) (io.ReadCloser, error) {
+	req, err := newRequest(BuildTarballURL(repo, ref))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpretry.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, statusError(resp, fmt.Sprintf("could not download %s at %s", repo, ref))
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,14 @@
+// ABOUTME: Tests for repository tarball downloads.
+// ABOUTME: Verifies codeload URL construction.
+
+package github
+
+import "testing"
+
+func TestBuildTarballURL(t *testing.T) {
+	got := BuildTarballURL("grafana/alloy", "v1.4.0")
+	want := "https://codeload.github.com/grafana/alloy/tar.gz/v1.4.0"
+	if got != want {
+		t.Errorf("BuildTarballURL() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,43 @@
+// ABOUTME: Lists repository files through the GitHub git trees API.
+// ABOUTME: Fetches the whole tree of a ref in one request, with file sizes.
+
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/bartriepe/my-docs/forge"
+	"github.com/bartriepe/my-docs/httpretry"
+)
+
+func BuildTreeURL(repo, ref string) string {
+	return fmt.Sprintf("%s/repos/%s/git/trees/%s?recursive=1", apiBaseURL, repo, url.PathEscape(ref))
+}
+
+// FetchTree lists every file and directory of repo at a branch, tag or commit.
+func FetchTree(repo, ref string) (*forge.Tree, error) {
+	req, err := newRequest(BuildTreeURL(repo, ref))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := httpretry.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp, fmt.Sprintf("could not list %s at %s", repo, ref))
+	}
+
+	var tree forge.Tree
+	if err := json.NewDecoder(resp.Body).Decode(&tree); err != nil {
+		return nil, err
+	}
+	return &tree, nil
+}
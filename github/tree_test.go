@@ -0,0 +1,21 @@
+// ABOUTME: Tests for repository tree listing.
+// ABOUTME: Verifies git trees API URL construction.
+
+package github
+
+import "testing"
+
+func TestBuildTreeURL(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"main", "https://api.github.com/repos/grafana/alloy/git/trees/main?recursive=1"},
+		{"release/1.4", "https://api.github.com/repos/grafana/alloy/git/trees/release%2F1.4?recursive=1"},
+	}
+	for _, tt := range tests {
+		if got := BuildTreeURL("grafana/alloy", tt.ref); got != tt.want {
+			t.Errorf("BuildTreeURL(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// ABOUTME: Client for the GitLab REST API on gitlab.com or a self-managed host.
+// ABOUTME: Reads raw files, lists trees and releases, finds default branches and downloads archives.
+
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bartriepe/my-docs/forge"
+)
+
+// maxTreePages caps how many pages of 100 entries a tree listing reads.
+const maxTreePages = 200
+
+// Client reads projects on one GitLab host. Projects are named by their full
+// path, which may include nested groups, e.g. "group/subgroup/project".
+type Client struct {
+	// BaseURL is the host's root URL, e.g. "https://gitlab.com".
+	BaseURL string
+	// Token is a personal or project access token; empty for anonymous access.
+	Token string
+}
+
+var (
+	_ forge.Provider = (*Client)(nil)
+	_ forge.Releaser = (*Client)(nil)
+)
+
+func New(baseURL, token string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), Token: token}
+}
+
+// BuildProjectURL is the API URL of a project. The project path is encoded
+// as a single segment, as GitLab requires.
+func (c *Client) BuildProjectURL(repo string) string {
+	return c.BaseURL + "/api/v4/projects/" + url.PathEscape(repo)
+}
+
+func (c *Client) BuildFileURL(repo, ref, path string) string {
+	return c.BuildProjectURL(repo) + "/repository/files/" + url.PathEscape(path) + "/raw?ref=" + url.QueryEscape(ref)
+}
+
+func (c *Client) BuildTreeURL(repo, ref string, page int) string {
+	params := url.Values{}
+	params.Set("ref", ref)
+	params.Set("recursive", "true")
+	params.Set("per_page", "100")
+	params.Set("page", strconv.Itoa(page))
+	return c.BuildProjectURL(repo) + "/repository/tree?" + params.Encode()
+}
+
+func (c *Client) BuildReleasesURL(repo string, page int) string {
+	return c.BuildProjectURL(repo) + "/releases?per_page=100&page=" + strconv.Itoa(page)
+}
+
+func (c *Client) BuildArchiveURL(repo, ref string) string {
+	return c.BuildProjectURL(repo) + "/repository/archive.tar.gz?sha=" + url.QueryEscape(ref)
+}
+
+// api is the host's API, authenticated with Token.
+func (c *Client) api() forge.API {
+	return forge.API{BaseURL: c.BaseURL, Token: c.Token, AuthHeader: "PRIVATE-TOKEN"}
+}
+
+// get sends an authenticated GET. Non-200 responses are closed and turned
+// into an error describing what was requested.
+func (c *Client) get(rawURL, what string) (*http.Response, error) {
+	return c.api().Get(rawURL, what)
+}
+
+func (c *Client) DefaultBranch(repo string) (string, error) {
+	resp, err := c.get(c.BuildProjectURL(repo), fmt.Sprintf("could not look up project %s", repo))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var project struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
+		return "", err
+	}
+	if project.DefaultBranch == "" {
+		return "", fmt.Errorf("project %s has no default branch", repo)
+	}
+	return project.DefaultBranch, nil
+}
+
+func (c *Client) OpenFile(repo, ref, path string) (io.ReadCloser, error) {
+	resp, err := c.get(c.BuildFileURL(repo, ref, path), fmt.Sprintf("could not fetch %s/%s at %s", repo, path, ref))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Tree lists a project page by page. GitLab does not report file sizes in
+// listings, so every entry's Size is -1.
+func (c *Client) Tree(repo, ref string) (*forge.Tree, error) {
+	tree := &forge.Tree{}
+	for page := 1; page != 0; {
+		if page > maxTreePages {
+			tree.Truncated = true
+			break
+		}
+		resp, err := c.get(c.BuildTreeURL(repo, ref, page), fmt.Sprintf("could not list %s at %s", repo, ref))
+		if err != nil {
+			return nil, err
+		}
+
+		var entries []struct {
+			ID   string `json:"id"`
+			Path string `json:"path"`
+			Type string `json:"type"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&entries)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			tree.Entries = append(tree.Entries, forge.TreeEntry{Path: e.Path, Type: e.Type, Size: -1, SHA: e.ID})
+		}
+
+		// X-Next-Page is empty on the last page.
+		page, _ = strconv.Atoi(resp.Header.Get("X-Next-Page"))
+	}
+	return tree, nil
+}
+
+func (c *Client) OpenTarball(repo, ref string) (io.ReadCloser, error) {
+	resp, err := c.get(c.BuildArchiveURL(repo, ref), fmt.Sprintf("could not download %s at %s", repo, ref))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ReleasePage returns one page of a project's releases, newest first, and
+// whether more follow.
+func (c *Client) ReleasePage(repo string, page int) ([]forge.Release, bool, error) {
+	resp, err := c.get(c.BuildReleasesURL(repo, page), fmt.Sprintf("could not list releases of %s", repo))
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	var releases []struct {
+		TagName     string `json:"tag_name"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		ReleasedAt  string `json:"released_at"`
+		Upcoming    bool   `json:"upcoming_release"`
+		Links       struct {
+			Self string `json:"self"`
+		} `json:"_links"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, false, err
+	}
+
+	var out []forge.Release
+	for _, r := range releases {
+		out = append(out, forge.Release{
+			Tag:        r.TagName,
+			Name:       r.Name,
+			Body:       r.Description,
+			Prerelease: r.Upcoming,
+			Published:  r.ReleasedAt,
+			URL:        r.Links.Self,
+		})
+	}
+	// X-Next-Page is empty on the last page.
+	return out, resp.Header.Get("X-Next-Page") != "", nil
+}
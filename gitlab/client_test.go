@@ -0,0 +1,112 @@
+// ABOUTME: Tests for the GitLab API client.
+// ABOUTME: Verifies URL encoding of nested project paths and reads against an httptest server.
+
+package gitlab
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bartriepe/my-docs/forge"
+)
+
+func TestBuildURLs(t *testing.T) {
+	c := New("https://gitlab.com/", "")
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"project", c.BuildProjectURL("group/sub/project"), "https://gitlab.com/api/v4/projects/group%2Fsub%2Fproject"},
+		{"file", c.BuildFileURL("group/project", "v1.0", "docs/my file.md"), "https://gitlab.com/api/v4/projects/group%2Fproject/repository/files/docs%2Fmy%20file.md/raw?ref=v1.0"},
+		{"tree", c.BuildTreeURL("group/project", "main", 2), "https://gitlab.com/api/v4/projects/group%2Fproject/repository/tree?page=2&per_page=100&recursive=true&ref=main"},
+		{"releases", c.BuildReleasesURL("group/project", 3), "https://gitlab.com/api/v4/projects/group%2Fproject/releases?per_page=100&page=3"},
+		{"archive", c.BuildArchiveURL("group/project", "release/1.0"), "https://gitlab.com/api/v4/projects/group%2Fproject/repository/archive.tar.gz?sha=release%2F1.0"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s URL = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+// newTestServer serves a nested-group project with a two-page tree.
+func newTestServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fsub%2Fproject":
+			fmt.Fprint(w, `{"id": 7, "default_branch": "develop"}`)
+		case "/api/v4/projects/group%2Fsub%2Fproject/repository/files/docs%2Fintro.md/raw":
+			fmt.Fprintf(w, "intro at %s\n", r.URL.Query().Get("ref"))
+		case "/api/v4/projects/group%2Fsub%2Fproject/repository/tree":
+			if r.URL.Query().Get("page") == "1" {
+				w.Header().Set("X-Next-Page", "2")
+				fmt.Fprint(w, `[{"path": "docs", "type": "tree"}, {"path": "docs/intro.md", "type": "blob"}]`)
+				return
+			}
+			w.Header().Set("X-Next-Page", "")
+			fmt.Fprint(w, `[{"path": "README.md", "type": "blob"}]`)
+		case "/api/v4/projects/group%2Fsub%2Fproject/releases":
+			if r.URL.Query().Get("page") == "1" {
+				w.Header().Set("X-Next-Page", "2")
+				fmt.Fprint(w, `[{"tag_name": "v1.1.0", "name": "1.1", "description": "Fixes", "released_at": "2026-01-02T00:00:00Z"}]`)
+				return
+			}
+			fmt.Fprint(w, `[{"tag_name": "v1.0.0"}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient(t *testing.T) {
+	srv := newTestServer(t)
+	c := New(srv.URL, "secret")
+
+	branch, err := c.DefaultBranch("group/sub/project")
+	if err != nil || branch != "develop" {
+		t.Errorf("DefaultBranch() = %q, %v, want %q", branch, err, "develop")
+	}
+
+	body, err := c.OpenFile("group/sub/project", "v2", "docs/intro.md")
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	content, _ := io.ReadAll(body)
+	body.Close()
+	if string(content) != "intro at v2\n" {
+		t.Errorf("OpenFile() content = %q, want %q", content, "intro at v2\n")
+	}
+
+	tree, err := c.Tree("group/sub/project", "develop")
+	if err != nil {
+		t.Fatalf("Tree() error = %v", err)
+	}
+	if len(tree.Entries) != 3 || tree.Entries[2].Path != "README.md" || tree.Entries[1].Size != -1 {
+		t.Errorf("Tree() = %+v, want both pages with unknown sizes", tree.Entries)
+	}
+
+	releases, err := forge.Releases(c, "group/sub/project", 10, nil)
+	if err != nil || len(releases) != 2 || releases[0].Tag != "v1.1.0" || releases[0].Body != "Fixes" || releases[1].Tag != "v1.0.0" {
+		t.Errorf("Releases() = %+v, %v, want v1.1.0 with its description and v1.0.0 from the next page", releases, err)
+	}
+}
+
+func TestClient_NotFound(t *testing.T) {
+	srv := newTestServer(t)
+
+	_, err := New(srv.URL, "").DefaultBranch("group/sub/project")
+	if err == nil || !strings.Contains(err.Error(), "or private: configure a token") {
+		t.Errorf("DefaultBranch() without token error = %v, want a hint about tokens", err)
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/bartriepe/my-docs
 
 go 1.25.4
+
+require golang.org/x/net v0.57.0
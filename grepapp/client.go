@@ -6,16 +6,22 @@ package grepapp
 import (
 	"encoding/json"
 	"fmt"
-	"html"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strconv"
-	"strings"
+
+	"github.com/bartriepe/my-docs/httpretry"
 )
 
 const baseURL = "https://grep.app/api/search"
 
+// PageSize is the number of hits grep.app returns per page.
+const PageSize = 10
+
+// MaxPages is the deepest result page grep.app will serve.
+const MaxPages = 100
+
 type Response struct {
 	Time   int    `json:"time"`
 	Facets Facets `json:"facets"`
@@ -47,29 +53,108 @@ type Hit struct {
 	Branch  string  `json:"branch"`
 	Path    string  `json:"path"`
 	Content Content `json:"content"`
+	// TotalMatches is grep.app's match count for the file, e.g. "5" or "100+".
+	TotalMatches string `json:"total_matches"`
+	// Lines holds the lines of hits found by a local search, which have no
+	// HTML snippet.
+	Lines []Match `json:"-"`
+}
+
+// Matches returns the lines of the hit: its parsed snippet, or Lines for
+// hits from a local search.
+func (h Hit) Matches() []Match {
+	if h.Lines != nil {
+		return h.Lines
+	}
+	return ExtractText(h.Content.Snippet)
 }
 
 type Content struct {
 	Snippet string `json:"snippet"`
 }
 
-type Match struct {
-	Line int
-	Text string
+// Query describes a single grep.app search. Empty filters are omitted.
+// Patterns are case-insensitive regular expressions unless the matching
+// options say otherwise.
+type Query struct {
+	Pattern       string
+	Repo          string
+	Lang          string
+	Path          string
+	Page          int    // 1-based; zero means the first page
+	RepoPattern   string // narrows the repo facet, e.g. "grafana/"
+	FixedStrings  bool
+	CaseSensitive bool
+	Words         bool
 }
 
-func BuildURL(query, repo string) string {
+// Regexp compiles the pattern with the query's matching options applied, as a
+// local equivalent of what grep.app will match.
+func (q Query) Regexp() (*regexp.Regexp, error) {
+	expr := q.Pattern
+	if q.FixedStrings {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if q.Words {
+		expr = `\b(?:` + expr + `)\b`
+	}
+	if !q.CaseSensitive {
+		expr = "(?i)" + expr
+	}
+	return regexp.Compile(expr)
+}
+
+// Validate checks that a regex pattern compiles before it is sent, so a bad
+// pattern fails with a clear error rather than an empty or failed search.
+func (q Query) Validate() error {
+	if q.FixedStrings {
+		return nil
+	}
+	if _, err := regexp.Compile(q.Pattern); err != nil {
+		return fmt.Errorf("invalid regular expression %q: %v (use --fixed-strings to search for it literally)", q.Pattern, err)
+	}
+	return nil
+}
+
+func BuildURL(q Query) string {
 	params := url.Values{}
-	params.Set("q", query)
-	params.Set("regexp", "true")
-	if repo != "" {
-		params.Set("f.repo", repo)
+	params.Set("q", q.Pattern)
+	if q.FixedStrings {
+		params.Set("regexp", "false")
+		if q.Words {
+			params.Set("words", "true")
+		}
+	} else {
+		params.Set("regexp", "true")
+		if q.Words {
+			// Whole-word regex searches are expressed in the pattern itself so
+			// they match exactly what Regexp matches locally.
+			params.Set("q", `\b(?:`+q.Pattern+`)\b`)
+		}
+	}
+	if q.CaseSensitive {
+		params.Set("case", "true")
+	}
+	if q.Repo != "" {
+		params.Set("f.repo", q.Repo)
+	}
+	if q.RepoPattern != "" {
+		params.Set("f.repo.pattern", q.RepoPattern)
+	}
+	if q.Lang != "" {
+		params.Set("f.lang", q.Lang)
+	}
+	if q.Path != "" {
+		params.Set("f.path", q.Path)
+	}
+	if q.Page > 1 {
+		params.Set("page", strconv.Itoa(q.Page))
 	}
 	return baseURL + "?" + params.Encode()
 }
 
-func Search(query, repo string) (*Response, error) {
-	searchURL := BuildURL(query, repo)
+func Search(q Query) (*Response, error) {
+	searchURL := BuildURL(q)
 
 	req, err := http.NewRequest("GET", searchURL, nil)
 	if err != nil {
@@ -77,7 +162,7 @@ func Search(query, repo string) (*Response, error) {
 	}
 	req.Header.Set("User-Agent", "my-docs/1.0")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpretry.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -94,34 +179,3 @@ func Search(query, repo string) (*Response, error) {
 
 	return &result, nil
 }
-
-var lineRegex = regexp.MustCompile(`data-line="(\d+)"`)
-var tagRegex = regexp.MustCompile(`<[^>]+>`)
-
-func ExtractText(snippet string) []Match {
-	var matches []Match
-
-	rows := strings.Split(snippet, "</tr>")
-	for _, row := range rows {
-		lineMatch := lineRegex.FindStringSubmatch(row)
-		if lineMatch == nil {
-			continue
-		}
-		lineNum, _ := strconv.Atoi(lineMatch[1])
-
-		preStart := strings.Index(row, "<pre>")
-		preEnd := strings.Index(row, "</pre>")
-		if preStart == -1 || preEnd == -1 {
-			continue
-		}
-
-		content := row[preStart+5 : preEnd]
-		text := tagRegex.ReplaceAllString(content, "")
-		text = html.UnescapeString(text)
-		text = strings.TrimSpace(text)
-
-		matches = append(matches, Match{Line: lineNum, Text: text})
-	}
-
-	return matches
-}
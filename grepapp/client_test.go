@@ -80,11 +80,14 @@ func TestParseResponse(t *testing.T) {
 	if hit.Path != "docs/intro.md" {
 		t.Errorf("Hit path = %q, want docs/intro.md", hit.Path)
 	}
+	if hit.TotalMatches != "5" {
+		t.Errorf("Hit total matches = %q, want 5", hit.TotalMatches)
+	}
 }
 
 func TestBuildURL(t *testing.T) {
 	t.Run("simple query", func(t *testing.T) {
-		got := BuildURL("test", "")
+		got := BuildURL(Query{Pattern: "test"})
 		if !strings.Contains(got, "q=test") {
 			t.Errorf("BuildURL() missing q param: %q", got)
 		}
@@ -94,7 +97,7 @@ func TestBuildURL(t *testing.T) {
 	})
 
 	t.Run("with repo filter", func(t *testing.T) {
-		got := BuildURL("prometheus", "grafana/alloy")
+		got := BuildURL(Query{Pattern: "prometheus", Repo: "grafana/alloy"})
 		if !strings.Contains(got, "q=prometheus") {
 			t.Errorf("BuildURL() missing q param: %q", got)
 		}
@@ -104,51 +107,121 @@ func TestBuildURL(t *testing.T) {
 	})
 
 	t.Run("query with spaces", func(t *testing.T) {
-		got := BuildURL("hello world", "")
+		got := BuildURL(Query{Pattern: "hello world"})
 		if !strings.Contains(got, "q=hello+world") {
 			t.Errorf("BuildURL() = %q, want q=hello+world", got)
 		}
 	})
+
+	t.Run("with lang and path filters", func(t *testing.T) {
+		got := BuildURL(Query{Pattern: "alloy", Repo: "grafana/alloy", Lang: "Markdown", Path: "docs/"})
+		if !strings.Contains(got, "f.lang=Markdown") {
+			t.Errorf("BuildURL() missing f.lang param: %q", got)
+		}
+		if !strings.Contains(got, "f.path=docs%2F") {
+			t.Errorf("BuildURL() missing f.path param: %q", got)
+		}
+	})
+
+	t.Run("page number", func(t *testing.T) {
+		if got := BuildURL(Query{Pattern: "alloy", Page: 3}); !strings.Contains(got, "page=3") {
+			t.Errorf("BuildURL() missing page param: %q", got)
+		}
+		if got := BuildURL(Query{Pattern: "alloy", Page: 1}); strings.Contains(got, "page=") {
+			t.Errorf("BuildURL() = %q, want no page param for first page", got)
+		}
+	})
+
+	t.Run("fixed strings", func(t *testing.T) {
+		got := BuildURL(Query{Pattern: "foo.Bar(", FixedStrings: true})
+		if !strings.Contains(got, "regexp=false") {
+			t.Errorf("BuildURL() = %q, want regexp=false", got)
+		}
+		if !strings.Contains(got, "q=foo.Bar%28") {
+			t.Errorf("BuildURL() = %q, want unescaped literal query", got)
+		}
+	})
+
+	t.Run("case sensitive", func(t *testing.T) {
+		if got := BuildURL(Query{Pattern: "Vec", CaseSensitive: true}); !strings.Contains(got, "case=true") {
+			t.Errorf("BuildURL() = %q, want case=true", got)
+		}
+		if got := BuildURL(Query{Pattern: "Vec"}); strings.Contains(got, "case=") {
+			t.Errorf("BuildURL() = %q, want no case param by default", got)
+		}
+	})
+
+	t.Run("whole words", func(t *testing.T) {
+		if got := BuildURL(Query{Pattern: "Vec<T>", FixedStrings: true, Words: true}); !strings.Contains(got, "words=true") {
+			t.Errorf("BuildURL() = %q, want words=true for literal search", got)
+		}
+		got := BuildURL(Query{Pattern: "get|set", Words: true})
+		if !strings.Contains(got, "q=%5Cb%28%3F%3Aget%7Cset%29%5Cb") {
+			t.Errorf("BuildURL() = %q, want pattern wrapped in word boundaries", got)
+		}
+	})
+
+	t.Run("empty filters omitted", func(t *testing.T) {
+		got := BuildURL(Query{Pattern: "alloy"})
+		if strings.Contains(got, "f.lang") || strings.Contains(got, "f.path") || strings.Contains(got, "f.repo") {
+			t.Errorf("BuildURL() = %q, want no filter params", got)
+		}
+	})
 }
 
-func TestExtractText(t *testing.T) {
+func TestQueryRegexp(t *testing.T) {
 	tests := []struct {
-		name    string
-		snippet string
-		want    []Match
+		name  string
+		query Query
+		input string
+		want  bool
 	}{
-		{
-			name:    "single line",
-			snippet: `<table class="highlight-table"><tr data-line="42"><td><div class="lineno">42</div></td><td><div class="highlight"><pre>some <mark>match</mark> here</pre></div></td></tr></table>`,
-			want:    []Match{{Line: 42, Text: "some match here"}},
-		},
-		{
-			name:    "multiple lines",
-			snippet: `<table class="highlight-table"><tr data-line="10"><td><div class="lineno">10</div></td><td><div class="highlight"><pre>first</pre></div></td></tr><tr data-line="11"><td><div class="lineno">11</div></td><td><div class="highlight"><pre>second</pre></div></td></tr></table>`,
-			want:    []Match{{Line: 10, Text: "first"}, {Line: 11, Text: "second"}},
-		},
-		{
-			name:    "html entities",
-			snippet: `<table class="highlight-table"><tr data-line="5"><td><div class="lineno">5</div></td><td><div class="highlight"><pre>&quot;hello&quot; &amp; &lt;world&gt;</pre></div></td></tr></table>`,
-			want:    []Match{{Line: 5, Text: `"hello" & <world>`}},
-		},
+		{name: "regex default", query: Query{Pattern: "func.*Start"}, input: "func (s *Server) Start()", want: true},
+		{name: "case insensitive by default", query: Query{Pattern: "start"}, input: "Start", want: true},
+		{name: "case sensitive", query: Query{Pattern: "start", CaseSensitive: true}, input: "Start", want: false},
+		{name: "fixed strings are literal", query: Query{Pattern: "foo.Bar(", FixedStrings: true}, input: "fooXBar(", want: false},
+		{name: "fixed strings match", query: Query{Pattern: "Vec<T>", FixedStrings: true}, input: "let v: Vec<T> = x", want: true},
+		{name: "words reject substrings", query: Query{Pattern: "alloc", Words: true}, input: "allocator", want: false},
+		{name: "words accept whole word", query: Query{Pattern: "alloc", Words: true}, input: "fn alloc()", want: true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := ExtractText(tt.snippet)
-			if len(got) != len(tt.want) {
-				t.Errorf("ExtractText() returned %d matches, want %d", len(got), len(tt.want))
-				return
+			re, err := tt.query.Regexp()
+			if err != nil {
+				t.Fatalf("Regexp() error = %v", err)
 			}
-			for i, m := range got {
-				if m.Line != tt.want[i].Line {
-					t.Errorf("Match[%d].Line = %d, want %d", i, m.Line, tt.want[i].Line)
-				}
-				if m.Text != tt.want[i].Text {
-					t.Errorf("Match[%d].Text = %q, want %q", i, m.Text, tt.want[i].Text)
-				}
+			if got := re.MatchString(tt.input); got != tt.want {
+				t.Errorf("Regexp().MatchString(%q) = %v, want %v", tt.input, got, tt.want)
 			}
 		})
 	}
 }
+
+func TestQueryValidate(t *testing.T) {
+	if err := (Query{Pattern: "foo.Bar("}).Validate(); err == nil {
+		t.Error("Validate() error = nil for unbalanced paren, want error")
+	} else if !strings.Contains(err.Error(), "--fixed-strings") {
+		t.Errorf("Validate() error = %q, want hint about --fixed-strings", err)
+	}
+
+	if err := (Query{Pattern: "foo.Bar(", FixedStrings: true}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v for fixed string, want nil", err)
+	}
+
+	if err := (Query{Pattern: "func.*Start"}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v for valid regex, want nil", err)
+	}
+}
+
+func TestHitMatches(t *testing.T) {
+	local := Hit{Lines: []Match{{Line: 7, Text: "x", Matched: true}}}
+	if got := local.Matches(); len(got) != 1 || got[0].Line != 7 {
+		t.Errorf("Matches() with Lines = %+v, want the local lines", got)
+	}
+
+	remote := Hit{Content: Content{Snippet: `<table><tr data-line="3"><td><pre><mark>x</mark></pre></td></tr></table>`}}
+	if got := remote.Matches(); len(got) != 1 || got[0].Line != 3 || !got[0].Matched {
+		t.Errorf("Matches() with snippet = %+v, want the parsed snippet", got)
+	}
+}
@@ -0,0 +1,201 @@
+// ABOUTME: Works with grep.app facet buckets: filter validation, org repos and merging.
+// ABOUTME: Corrects near-miss filter values and suggests alternatives for unknown ones.
+
+package grepapp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const maxSuggestions = 3
+
+// ResolveFilters checks q.Lang and q.Path against the facets grep.app reports
+// for the same query without those filters. It is meant to be called when a
+// filtered search returns no hits, to tell a typo apart from a genuine miss.
+func ResolveFilters(q Query) (Query, error) {
+	unfiltered := q
+	unfiltered.Lang = ""
+	unfiltered.Path = ""
+
+	resp, err := Search(unfiltered)
+	if err != nil {
+		return q, err
+	}
+	return CheckFilters(q, resp.Facets)
+}
+
+// CheckFilters returns q with its language and path filters rewritten to the
+// canonical bucket values in facets. Values that differ only in case, or a path
+// missing its trailing slash, are corrected. Values with no matching bucket
+// produce an error listing the closest buckets.
+func CheckFilters(q Query, facets Facets) (Query, error) {
+	if q.Lang != "" && len(facets.Lang.Buckets) > 0 {
+		lang, ok := findBucket(q.Lang, facets.Lang.Buckets)
+		if !ok {
+			return q, unknownFilterError("language", q.Lang, facets.Lang.Buckets)
+		}
+		q.Lang = lang
+	}
+
+	if q.Path != "" && len(facets.Path.Buckets) > 0 {
+		top, rest, _ := strings.Cut(q.Path, "/")
+		dir, ok := findBucket(top+"/", facets.Path.Buckets)
+		if !ok {
+			return q, unknownFilterError("path", q.Path, facets.Path.Buckets)
+		}
+		q.Path = dir + rest
+	}
+
+	return q, nil
+}
+
+// OrgRepos returns the repos under owner that have matches for q, most
+// matches first, as reported by the repo facet.
+func OrgRepos(q Query, owner string) ([]string, error) {
+	q.Repo = ""
+	q.RepoPattern = owner + "/"
+	resp, err := Search(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []string
+	for _, b := range resp.Facets.Repo.Buckets {
+		if strings.HasPrefix(strings.ToLower(b.Val), strings.ToLower(owner)+"/") {
+			repos = append(repos, b.Val)
+		}
+	}
+	return repos, nil
+}
+
+// Indexed reports whether grep.app indexes repo, by looking for it in the
+// repo facet of a search that matches nearly every file.
+func Indexed(repo string) (bool, error) {
+	resp, err := Search(Query{Pattern: "e", Repo: repo, FixedStrings: true})
+	if err != nil {
+		return false, err
+	}
+	for _, b := range resp.Facets.Repo.Buckets {
+		if strings.EqualFold(b.Val, repo) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MergeFacets combines the responses of one query run against several repos:
+// totals are summed, bucket counts are added up per value and re-sorted.
+func MergeFacets(resps []*Response) *Response {
+	merged := &Response{}
+	for _, r := range resps {
+		merged.Time = max(merged.Time, r.Time)
+		merged.Hits.Total += r.Hits.Total
+	}
+
+	groups := func(r *Response) []*FacetGroup {
+		return []*FacetGroup{&r.Facets.Repo, &r.Facets.Lang, &r.Facets.Path}
+	}
+	for i, dst := range groups(merged) {
+		counts := make(map[string]int)
+		var order []string
+		for _, r := range resps {
+			for _, b := range groups(r)[i].Buckets {
+				if _, ok := counts[b.Val]; !ok {
+					order = append(order, b.Val)
+				}
+				counts[b.Val] += b.Count
+			}
+		}
+		for _, val := range order {
+			dst.Buckets = append(dst.Buckets, Bucket{Val: val, Count: counts[val]})
+		}
+		sort.SliceStable(dst.Buckets, func(a, b int) bool {
+			return dst.Buckets[a].Count > dst.Buckets[b].Count
+		})
+	}
+	return merged
+}
+
+func findBucket(val string, buckets []Bucket) (string, bool) {
+	for _, b := range buckets {
+		if b.Val == val {
+			return b.Val, true
+		}
+	}
+	for _, b := range buckets {
+		if strings.EqualFold(b.Val, val) {
+			return b.Val, true
+		}
+	}
+	return "", false
+}
+
+func unknownFilterError(kind, val string, buckets []Bucket) error {
+	suggestions := Suggest(val, buckets)
+	if len(suggestions) > 0 {
+		return fmt.Errorf("no %s %q for this query (did you mean: %s?)", kind, val, strings.Join(suggestions, ", "))
+	}
+
+	var available []string
+	for _, b := range buckets {
+		available = append(available, b.Val)
+	}
+	return fmt.Errorf("no %s %q for this query (available: %s)", kind, val, strings.Join(available, ", "))
+}
+
+// Suggest returns up to three bucket values that look like val, closest first.
+// Substring matches rank ahead of values that are merely a few edits away.
+func Suggest(val string, buckets []Bucket) []string {
+	type candidate struct {
+		val   string
+		score int
+	}
+
+	needle := strings.ToLower(strings.TrimSuffix(val, "/"))
+	var candidates []candidate
+	for _, b := range buckets {
+		hay := strings.ToLower(strings.TrimSuffix(b.Val, "/"))
+		switch {
+		case strings.Contains(hay, needle) || strings.Contains(needle, hay):
+			candidates = append(candidates, candidate{b.Val, 0})
+		default:
+			d := levenshtein(needle, hay)
+			if d <= max(2, len(needle)/3) {
+				candidates = append(candidates, candidate{b.Val, d})
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score < candidates[j].score
+	})
+
+	var out []string
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		out = append(out, candidates[i].val)
+	}
+	return out
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,137 @@
+// ABOUTME: Tests for facet-based filter validation.
+// ABOUTME: Verifies canonicalisation of filter values and suggestion ranking.
+
+package grepapp
+
+import (
+	"strings"
+	"testing"
+)
+
+var testFacets = Facets{
+	Lang: FacetGroup{Buckets: []Bucket{
+		{Val: "Markdown", Count: 500},
+		{Val: "Go", Count: 300},
+		{Val: "JavaScript", Count: 200},
+	}},
+	Path: FacetGroup{Buckets: []Bucket{
+		{Val: "docs/", Count: 300},
+		{Val: "internal/", Count: 200},
+		{Val: "vendor/", Count: 100},
+	}},
+}
+
+func TestCheckFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    Query
+		wantLang string
+		wantPath string
+	}{
+		{name: "exact values", query: Query{Lang: "Go", Path: "docs/"}, wantLang: "Go", wantPath: "docs/"},
+		{name: "case corrected", query: Query{Lang: "markdown", Path: "Docs/"}, wantLang: "Markdown", wantPath: "docs/"},
+		{name: "missing trailing slash", query: Query{Path: "docs"}, wantPath: "docs/"},
+		{name: "nested path keeps remainder", query: Query{Path: "docs/sources/"}, wantPath: "docs/sources/"},
+		{name: "no filters", query: Query{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CheckFilters(tt.query, testFacets)
+			if err != nil {
+				t.Fatalf("CheckFilters() error = %v", err)
+			}
+			if got.Lang != tt.wantLang {
+				t.Errorf("CheckFilters().Lang = %q, want %q", got.Lang, tt.wantLang)
+			}
+			if got.Path != tt.wantPath {
+				t.Errorf("CheckFilters().Path = %q, want %q", got.Path, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestCheckFilters_Unknown(t *testing.T) {
+	t.Run("language with suggestion", func(t *testing.T) {
+		_, err := CheckFilters(Query{Lang: "Javascrpt"}, testFacets)
+		if err == nil {
+			t.Fatal("CheckFilters() error = nil, want error")
+		}
+		if !strings.Contains(err.Error(), "did you mean: JavaScript?") {
+			t.Errorf("CheckFilters() error = %q, want JavaScript suggestion", err)
+		}
+	})
+
+	t.Run("path without close match lists buckets", func(t *testing.T) {
+		_, err := CheckFilters(Query{Path: "examples/"}, testFacets)
+		if err == nil {
+			t.Fatal("CheckFilters() error = nil, want error")
+		}
+		if !strings.Contains(err.Error(), "available: docs/, internal/, vendor/") {
+			t.Errorf("CheckFilters() error = %q, want available buckets", err)
+		}
+	})
+
+	t.Run("no buckets skips validation", func(t *testing.T) {
+		got, err := CheckFilters(Query{Lang: "Cobol"}, Facets{})
+		if err != nil {
+			t.Fatalf("CheckFilters() error = %v", err)
+		}
+		if got.Lang != "Cobol" {
+			t.Errorf("CheckFilters().Lang = %q, want Cobol", got.Lang)
+		}
+	})
+}
+
+func TestSuggest(t *testing.T) {
+	buckets := []Bucket{{Val: "Go"}, {Val: "Rust"}, {Val: "Markdown"}, {Val: "Go Module"}}
+
+	got := Suggest("golang", buckets)
+	if len(got) == 0 || got[0] != "Go" {
+		t.Errorf("Suggest(golang) = %v, want Go first", got)
+	}
+
+	got = Suggest("Rsut", buckets)
+	if len(got) != 1 || got[0] != "Rust" {
+		t.Errorf("Suggest(Rsut) = %v, want [Rust]", got)
+	}
+
+	got = Suggest("Haskell", buckets)
+	if len(got) != 0 {
+		t.Errorf("Suggest(Haskell) = %v, want none", got)
+	}
+}
+
+func TestMergeFacets(t *testing.T) {
+	a := &Response{
+		Time:   100,
+		Facets: Facets{Lang: FacetGroup{Buckets: []Bucket{{Val: "Go", Count: 5}, {Val: "Markdown", Count: 4}}}},
+		Hits:   Hits{Total: 9},
+	}
+	b := &Response{
+		Time:   250,
+		Facets: Facets{Lang: FacetGroup{Buckets: []Bucket{{Val: "Markdown", Count: 3}, {Val: "YAML", Count: 1}}}},
+		Hits:   Hits{Total: 4},
+	}
+
+	got := MergeFacets([]*Response{a, b})
+
+	if got.Time != 250 {
+		t.Errorf("MergeFacets().Time = %d, want 250", got.Time)
+	}
+	if got.Hits.Total != 13 {
+		t.Errorf("MergeFacets().Hits.Total = %d, want 13", got.Hits.Total)
+	}
+	want := []Bucket{{Val: "Markdown", Count: 7}, {Val: "Go", Count: 5}, {Val: "YAML", Count: 1}}
+	if len(got.Facets.Lang.Buckets) != len(want) {
+		t.Fatalf("MergeFacets() lang buckets = %v, want %v", got.Facets.Lang.Buckets, want)
+	}
+	for i := range want {
+		if got.Facets.Lang.Buckets[i] != want[i] {
+			t.Errorf("MergeFacets() lang bucket %d = %v, want %v", i, got.Facets.Lang.Buckets[i], want[i])
+		}
+	}
+	if len(got.Facets.Repo.Buckets) != 0 {
+		t.Errorf("MergeFacets() repo buckets = %v, want none", got.Facets.Repo.Buckets)
+	}
+}
@@ -0,0 +1,178 @@
+// ABOUTME: Walks grep.app result pages in order for one query or several repos.
+// ABOUTME: Fetches batches of pages concurrently once the total is known.
+
+package grepapp
+
+import "sync"
+
+// DefaultConcurrency is how many pages a Pager fetches at once.
+const DefaultConcurrency = 4
+
+// Pager reads the result pages of a query in order. The first call to Next
+// fetches page one on its own to learn the total; later calls fetch a batch
+// of pages concurrently.
+type Pager struct {
+	// First is the response for page one, set after the first call to Next.
+	// It carries the facets and the total hit count for the whole query.
+	First *Response
+
+	query       Query
+	concurrency int
+	fetch       func(Query) (*Response, error)
+	next        int
+	last        int
+}
+
+func NewPager(q Query) *Pager {
+	return &Pager{
+		query:       q,
+		concurrency: DefaultConcurrency,
+		fetch:       Search,
+		next:        1,
+	}
+}
+
+// Total returns the number of matching files grep.app reported, or zero
+// before the first page has been fetched.
+func (p *Pager) Total() int {
+	if p.First == nil {
+		return 0
+	}
+	return p.First.Hits.Total
+}
+
+// RepoCounts returns the per-repo match counts from the repo facet of the
+// first page, or nil before it has been fetched.
+func (p *Pager) RepoCounts() map[string]int {
+	if p.First == nil {
+		return nil
+	}
+	counts := make(map[string]int)
+	for _, b := range p.First.Facets.Repo.Buckets {
+		counts[b.Val] = b.Count
+	}
+	return counts
+}
+
+// Done reports whether every available page has been returned.
+func (p *Pager) Done() bool {
+	return p.First != nil && p.next > p.last
+}
+
+// Next returns the hits of the next batch of pages in page order. It returns
+// an empty slice once all pages have been read.
+func (p *Pager) Next() ([]Hit, error) {
+	if p.First == nil {
+		resp, err := p.fetch(p.query)
+		if err != nil {
+			return nil, err
+		}
+		p.First = resp
+		p.next = 2
+		p.last = min(MaxPages, (resp.Hits.Total+PageSize-1)/PageSize)
+		if len(resp.Hits.Hits) < PageSize {
+			p.last = 1
+		}
+		return resp.Hits.Hits, nil
+	}
+
+	if p.Done() {
+		return nil, nil
+	}
+
+	first := p.next
+	last := min(p.last, first+p.concurrency-1)
+	pages := make([]*Response, last-first+1)
+	errs := make([]error, len(pages))
+
+	var wg sync.WaitGroup
+	for i := range pages {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			q := p.query
+			q.Page = first + i
+			pages[i], errs[i] = p.fetch(q)
+		}(i)
+	}
+	wg.Wait()
+
+	var hits []Hit
+	for i, resp := range pages {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		hits = append(hits, resp.Hits.Hits...)
+		if len(resp.Hits.Hits) < PageSize {
+			// A short page is the last one, whatever the total said.
+			p.last = first + i
+			break
+		}
+	}
+	p.next = last + 1
+	return hits, nil
+}
+
+// MultiPager merges the results of one query run against several repos into
+// one stream. Each call to Next advances every repo by one batch concurrently
+// and returns the hits in repo order, so the stream order is stable.
+type MultiPager struct {
+	pagers []*Pager
+}
+
+// NewMultiPager creates a pager per query. Each query is normally the same
+// search scoped to a different repo, and possibly a different path within it.
+func NewMultiPager(queries []Query) *MultiPager {
+	m := &MultiPager{}
+	for _, q := range queries {
+		m.pagers = append(m.pagers, NewPager(q))
+	}
+	return m
+}
+
+// Total returns the sum of the matching file counts of all repos.
+func (m *MultiPager) Total() int {
+	total := 0
+	for _, p := range m.pagers {
+		total += p.Total()
+	}
+	return total
+}
+
+// RepoCounts merges the repo facet counts of all repos.
+func (m *MultiPager) RepoCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, p := range m.pagers {
+		for repo, n := range p.RepoCounts() {
+			counts[repo] += n
+		}
+	}
+	return counts
+}
+
+func (m *MultiPager) Next() ([]Hit, error) {
+	batches := make([][]Hit, len(m.pagers))
+	errs := make([]error, len(m.pagers))
+
+	var wg sync.WaitGroup
+	for i, p := range m.pagers {
+		if p.Done() {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, p *Pager) {
+			defer wg.Done()
+			batches[i], errs[i] = p.Next()
+		}(i, p)
+	}
+	wg.Wait()
+
+	var hits []Hit
+	for i, batch := range batches {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		hits = append(hits, batch...)
+	}
+	return hits, nil
+}
@@ -0,0 +1,186 @@
+// ABOUTME: Tests for the grep.app result pager.
+// ABOUTME: Uses a fake fetch function to simulate paged responses.
+
+package grepapp
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// fakePages serves total hits, PageSize per page, and records requested pages.
+func fakePages(total int) (func(Query) (*Response, error), *[]int) {
+	var mu sync.Mutex
+	var requested []int
+	fetch := func(q Query) (*Response, error) {
+		page := max(q.Page, 1)
+		mu.Lock()
+		requested = append(requested, page)
+		mu.Unlock()
+
+		resp := &Response{Hits: Hits{Total: total}}
+		for i := (page - 1) * PageSize; i < min(page*PageSize, total); i++ {
+			resp.Hits.Hits = append(resp.Hits.Hits, Hit{Path: fmt.Sprintf("file%d.go", i)})
+		}
+		return resp, nil
+	}
+	return fetch, &requested
+}
+
+func TestPager_ReadsAllPagesInOrder(t *testing.T) {
+	fetch, requested := fakePages(73)
+	p := NewPager(Query{Pattern: "x"})
+	p.fetch = fetch
+
+	var paths []string
+	for {
+		hits, err := p.Next()
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if len(hits) == 0 {
+			break
+		}
+		for _, h := range hits {
+			paths = append(paths, h.Path)
+		}
+	}
+
+	if len(paths) != 73 {
+		t.Fatalf("read %d hits, want 73", len(paths))
+	}
+	for i, path := range paths {
+		if want := fmt.Sprintf("file%d.go", i); path != want {
+			t.Fatalf("hit %d = %q, want %q", i, path, want)
+		}
+	}
+	if len(*requested) != 8 {
+		t.Errorf("requested %d pages, want 8", len(*requested))
+	}
+	if p.Total() != 73 {
+		t.Errorf("Total() = %d, want 73", p.Total())
+	}
+	if !p.Done() {
+		t.Error("Done() = false after exhausting pages")
+	}
+}
+
+func TestPager_FirstCallFetchesOnePage(t *testing.T) {
+	fetch, requested := fakePages(500)
+	p := NewPager(Query{Pattern: "x"})
+	p.fetch = fetch
+
+	hits, err := p.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if len(hits) != PageSize {
+		t.Errorf("first batch = %d hits, want %d", len(hits), PageSize)
+	}
+	if len(*requested) != 1 {
+		t.Errorf("first call requested %d pages, want 1", len(*requested))
+	}
+
+	hits, err = p.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if len(hits) != DefaultConcurrency*PageSize {
+		t.Errorf("second batch = %d hits, want %d", len(hits), DefaultConcurrency*PageSize)
+	}
+}
+
+func TestPager_StopsAtMaxPages(t *testing.T) {
+	fetch, requested := fakePages(1_000_000)
+	p := NewPager(Query{Pattern: "x"})
+	p.fetch = fetch
+
+	for {
+		hits, err := p.Next()
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if len(hits) == 0 {
+			break
+		}
+	}
+	if len(*requested) != MaxPages {
+		t.Errorf("requested %d pages, want %d", len(*requested), MaxPages)
+	}
+}
+
+func TestPager_RepoCounts(t *testing.T) {
+	p := NewPager(Query{Pattern: "x"})
+	p.fetch = func(Query) (*Response, error) {
+		return &Response{Facets: Facets{Repo: FacetGroup{Buckets: []Bucket{{Val: "a/b", Count: 7}}}}}, nil
+	}
+
+	if p.RepoCounts() != nil {
+		t.Error("RepoCounts() before first page should be nil")
+	}
+	if _, err := p.Next(); err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if got := p.RepoCounts()["a/b"]; got != 7 {
+		t.Errorf("RepoCounts()[a/b] = %d, want 7", got)
+	}
+}
+
+func TestPager_PropagatesErrors(t *testing.T) {
+	p := NewPager(Query{Pattern: "x"})
+	p.fetch = func(Query) (*Response, error) { return nil, fmt.Errorf("boom") }
+
+	if _, err := p.Next(); err == nil {
+		t.Error("Next() error = nil, want error")
+	}
+}
+
+func TestMultiPager_InterleavesReposInOrder(t *testing.T) {
+	totals := map[string]int{"a/one": 25, "b/two": 3}
+	fetch := func(q Query) (*Response, error) {
+		page := max(q.Page, 1)
+		total := totals[q.Repo]
+		resp := &Response{Hits: Hits{Total: total}}
+		for i := (page - 1) * PageSize; i < min(page*PageSize, total); i++ {
+			resp.Hits.Hits = append(resp.Hits.Hits, Hit{Repo: q.Repo, Path: fmt.Sprintf("f%d", i)})
+		}
+		return resp, nil
+	}
+
+	m := NewMultiPager([]Query{{Pattern: "x", Repo: "a/one"}, {Pattern: "x", Repo: "b/two"}})
+	for _, p := range m.pagers {
+		p.fetch = fetch
+	}
+
+	first, err := m.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if len(first) != 13 {
+		t.Fatalf("first batch = %d hits, want 13", len(first))
+	}
+	if first[0].Repo != "a/one" || first[10].Repo != "b/two" {
+		t.Errorf("first batch order = %s then %s, want a/one then b/two", first[0].Repo, first[10].Repo)
+	}
+	if m.Total() != 28 {
+		t.Errorf("Total() = %d, want 28", m.Total())
+	}
+
+	second, err := m.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if len(second) != 15 {
+		t.Errorf("second batch = %d hits, want 15", len(second))
+	}
+	for _, h := range second {
+		if h.Repo != "a/one" {
+			t.Errorf("second batch contains %s, want only a/one", h.Repo)
+		}
+	}
+
+	if rest, _ := m.Next(); len(rest) != 0 {
+		t.Errorf("third batch = %d hits, want 0", len(rest))
+	}
+}
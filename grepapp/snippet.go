@@ -0,0 +1,182 @@
+// ABOUTME: Parses grep.app's HTML snippets into numbered lines with match ranges.
+// ABOUTME: Walks the HTML tokens of each snippet, tolerating nested tables and marks spanning rows.
+
+package grepapp
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// Match is one line of a snippet.
+type Match struct {
+	Line    int
+	Text    string
+	Matched bool // false for context rows grep.app includes around matches
+	// Ranges are the byte ranges of Text grep.app highlighted as matching.
+	Ranges []Range
+}
+
+// Range is a half-open byte range [Start, End) within a line.
+type Range struct {
+	Start int
+	End   int
+}
+
+// ExtractText turns a snippet into its lines. Each `<tr data-line="N">` row
+// contributes the text of its `<pre>` element; `<mark>` elements become
+// Ranges. A row whose text holds several lines is split into consecutive line
+// numbers, and a mark left open at the end of a row continues into the next.
+func ExtractText(snippet string) []Match {
+	var matches []Match
+
+	var row *rowBuilder
+	var rowDepth int // nesting depth of <tr> inside the current row
+	preDepth := 0
+	markOpen := false
+
+	z := html.NewTokenizer(strings.NewReader(snippet))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "tr":
+				if row != nil {
+					rowDepth++
+					continue
+				}
+				n, err := strconv.Atoi(dataLine(z, hasAttr))
+				if err != nil {
+					continue
+				}
+				row = &rowBuilder{line: n, markStart: -1}
+				rowDepth = 0
+				if markOpen {
+					row.openMark()
+				}
+			case "pre":
+				preDepth++
+				if row != nil {
+					row.hasPre = true
+				}
+				if row != nil && markOpen && row.markStart < 0 {
+					row.openMark()
+				}
+			case "mark":
+				if row != nil && preDepth > 0 && !markOpen {
+					row.openMark()
+				}
+				markOpen = true
+			case "br":
+				if row != nil && preDepth > 0 {
+					row.text.WriteString("\n")
+				}
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "tr":
+				if row == nil {
+					continue
+				}
+				if rowDepth > 0 {
+					rowDepth--
+					continue
+				}
+				if row.hasPre {
+					matches = append(matches, row.finish()...)
+				}
+				row = nil
+				preDepth = 0
+			case "pre":
+				preDepth = max(0, preDepth-1)
+			case "mark":
+				if row != nil && markOpen {
+					row.closeMark()
+				}
+				markOpen = false
+			}
+
+		case html.TextToken:
+			if row != nil && preDepth > 0 {
+				row.text.Write(z.Text())
+			}
+		}
+	}
+
+	// Tolerate a snippet cut off before its final </tr>.
+	if row != nil && row.hasPre {
+		matches = append(matches, row.finish()...)
+	}
+	return matches
+}
+
+// rowBuilder accumulates the text and mark ranges of one snippet row.
+type rowBuilder struct {
+	line      int
+	text      strings.Builder
+	ranges    []Range
+	markStart int // -1 when no mark is open in this row
+	hasPre    bool
+}
+
+func (r *rowBuilder) openMark() {
+	r.markStart = r.text.Len()
+}
+
+func (r *rowBuilder) closeMark() {
+	if r.markStart >= 0 && r.text.Len() > r.markStart {
+		r.ranges = append(r.ranges, Range{Start: r.markStart, End: r.text.Len()})
+	}
+	r.markStart = -1
+}
+
+// finish splits the row into lines and trims each, shifting ranges to match.
+func (r *rowBuilder) finish() []Match {
+	if r.markStart >= 0 {
+		r.closeMark()
+	}
+
+	var out []Match
+	text := r.text.String()
+	offset := 0
+	for i, raw := range strings.Split(text, "\n") {
+		lead := len(raw) - len(strings.TrimLeftFunc(raw, unicode.IsSpace))
+		trimmed := strings.TrimSpace(raw)
+
+		m := Match{Line: r.line + i, Text: trimmed}
+		for _, rg := range r.ranges {
+			start := max(rg.Start-offset-lead, 0)
+			end := min(rg.End-offset-lead, len(trimmed))
+			if start < end {
+				m.Ranges = append(m.Ranges, Range{Start: start, End: end})
+			}
+		}
+		m.Matched = len(m.Ranges) > 0
+		out = append(out, m)
+		offset += len(raw) + 1
+	}
+	return out
+}
+
+// dataLine returns the data-line attribute of the start tag z is on.
+func dataLine(z *html.Tokenizer, more bool) string {
+	for more {
+		var key, val []byte
+		key, val, more = z.TagAttr()
+		if string(key) == "data-line" {
+			return string(val)
+		}
+	}
+	return ""
+}
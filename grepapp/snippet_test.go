@@ -0,0 +1,135 @@
+// ABOUTME: Tests for grep.app snippet parsing.
+// ABOUTME: Verifies line text, match ranges, nested tables and marks spanning rows.
+
+package grepapp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractText(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    []Match
+	}{
+		{
+			name:    "single line",
+			snippet: `<table class="highlight-table"><tr data-line="42"><td><div class="lineno">42</div></td><td><div class="highlight"><pre>some <mark>match</mark> here</pre></div></td></tr></table>`,
+			want:    []Match{{Line: 42, Text: "some match here", Matched: true}},
+		},
+		{
+			name:    "multiple lines",
+			snippet: `<table class="highlight-table"><tr data-line="10"><td><div class="lineno">10</div></td><td><div class="highlight"><pre>first</pre></div></td></tr><tr data-line="11"><td><div class="lineno">11</div></td><td><div class="highlight"><pre>second</pre></div></td></tr></table>`,
+			want:    []Match{{Line: 10, Text: "first"}, {Line: 11, Text: "second"}},
+		},
+		{
+			name:    "html entities",
+			snippet: `<table class="highlight-table"><tr data-line="5"><td><div class="lineno">5</div></td><td><div class="highlight"><pre>&quot;hello&quot; &amp; &lt;world&gt;</pre></div></td></tr></table>`,
+			want:    []Match{{Line: 5, Text: `"hello" & <world>`}},
+		},
+		{
+			name:    "context rows are not matched",
+			snippet: `<table class="highlight-table"><tr data-line="7"><td><div class="lineno">7</div></td><td><div class="highlight"><pre>before</pre></div></td></tr><tr data-line="8"><td><div class="lineno">8</div></td><td><div class="highlight"><pre><span class="n"><mark>hit</mark></span></pre></div></td></tr></table>`,
+			want:    []Match{{Line: 7, Text: "before"}, {Line: 8, Text: "hit", Matched: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractText(tt.snippet)
+			if len(got) != len(tt.want) {
+				t.Errorf("ExtractText() returned %d matches, want %d", len(got), len(tt.want))
+				return
+			}
+			for i, m := range got {
+				if m.Line != tt.want[i].Line {
+					t.Errorf("Match[%d].Line = %d, want %d", i, m.Line, tt.want[i].Line)
+				}
+				if m.Text != tt.want[i].Text {
+					t.Errorf("Match[%d].Text = %q, want %q", i, m.Text, tt.want[i].Text)
+				}
+				if m.Matched != tt.want[i].Matched {
+					t.Errorf("Match[%d].Matched = %v, want %v", i, m.Matched, tt.want[i].Matched)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractText_Ranges(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    []Match
+	}{
+		{
+			name:    "highlighted spans",
+			snippet: `<table><tr data-line="37"><td><div class="lineno">37</div></td><td><div class="highlight"><pre><span class="c1">// safely <mark>allo</mark>cating, <mark>allo</mark>cated</span></pre></div></td></tr></table>`,
+			want:    []Match{{Line: 37, Text: "// safely allocating, allocated", Matched: true, Ranges: []Range{{10, 14}, {22, 26}}}},
+		},
+		{
+			name:    "leading whitespace trimmed from ranges",
+			snippet: `<table><tr data-line="3"><td><pre><span class="w">    </span><mark>fn</mark> main()</pre></td></tr></table>`,
+			want:    []Match{{Line: 3, Text: "fn main()", Matched: true, Ranges: []Range{{0, 2}}}},
+		},
+		{
+			name:    "entities inside mark",
+			snippet: `<table><tr data-line="5"><td><pre>let v: <mark>Vec&lt;T&gt;</mark> = x;</pre></td></tr></table>`,
+			want:    []Match{{Line: 5, Text: "let v: Vec<T> = x;", Matched: true, Ranges: []Range{{7, 13}}}},
+		},
+		{
+			name: "mark spanning rows",
+			snippet: `<table><tr data-line="10"><td><pre>start <mark>multi</pre></td></tr>` +
+				`<tr data-line="11"><td><pre>line</mark> end</pre></td></tr></table>`,
+			want: []Match{
+				{Line: 10, Text: "start multi", Matched: true, Ranges: []Range{{6, 11}}},
+				{Line: 11, Text: "line end", Matched: true, Ranges: []Range{{0, 4}}},
+			},
+		},
+		{
+			name:    "row with several lines",
+			snippet: `<table><tr data-line="20"><td><pre>first<br><mark>second</mark>` + "\n" + `third</pre></td></tr></table>`,
+			want: []Match{
+				{Line: 20, Text: "first"},
+				{Line: 21, Text: "second", Matched: true, Ranges: []Range{{0, 6}}},
+				{Line: 22, Text: "third"},
+			},
+		},
+		{
+			name: "nested table inside a row",
+			snippet: `<table><tr data-line="7"><td><table><tr><td>ignored</td></tr></table></td>` +
+				`<td><div class="highlight"><pre>outer <mark>hit</mark></pre></div></td></tr>` +
+				`<tr data-line="8"><td><pre>next</pre></td></tr></table>`,
+			want: []Match{
+				{Line: 7, Text: "outer hit", Matched: true, Ranges: []Range{{6, 9}}},
+				{Line: 8, Text: "next"},
+			},
+		},
+		{
+			name:    "attributes in any quoting and comments",
+			snippet: `<!-- snippet --><table><tr class='x' data-line=12 id="r"><td><pre>a <MARK>b</MARK> c</pre></td></tr></table>`,
+			want:    []Match{{Line: 12, Text: "a b c", Matched: true, Ranges: []Range{{2, 3}}}},
+		},
+		{
+			name:    "rows without pre are skipped",
+			snippet: `<table><tr data-line="1"><td>no code</td></tr><tr data-line="2"><td><pre>code</pre></td></tr></table>`,
+			want:    []Match{{Line: 2, Text: "code"}},
+		},
+		{
+			name:    "stray angle bracket is text",
+			snippet: `<table><tr data-line="4"><td><pre>if a < b { <mark>x</mark> }</pre></td></tr></table>`,
+			want:    []Match{{Line: 4, Text: "if a < b { x }", Matched: true, Ranges: []Range{{11, 12}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractText(tt.snippet)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractText() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+// ABOUTME: Shared HTTP transport that retries failures and respects rate limits.
+// ABOUTME: Honours Retry-After and X-RateLimit-* headers and backs off with jitter.
+
+package httpretry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Client is the HTTP client every my-docs API client uses.
+var Client = &http.Client{Transport: &Transport{}}
+
+// Do sends req with Client. A rate limit error is returned as is rather than
+// wrapped in a *url.Error, so the message leads with the wait.
+func Do(req *http.Request) (*http.Response, error) {
+	resp, err := Client.Do(req)
+	var limit *RateLimitError
+	if errors.As(err, &limit) {
+		return nil, limit
+	}
+	return resp, err
+}
+
+const (
+	defaultMaxRetries = 4
+	defaultBaseDelay  = 500 * time.Millisecond
+	defaultMaxDelay   = 10 * time.Second
+	defaultMaxWait    = 30 * time.Second
+)
+
+// Transport retries network errors and 5xx responses with jittered
+// exponential backoff. Rate-limited responses (429, or 403 with no requests
+// remaining) are retried after the wait the server asks for when it is short,
+// and otherwise fail with a *RateLimitError. Zero fields use the defaults.
+type Transport struct {
+	Base       http.RoundTripper
+	MaxRetries int
+	// BaseDelay is the backoff before the first retry; it doubles with each
+	// attempt up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// MaxWait is the longest rate-limit wait worth sleeping through.
+	MaxWait time.Duration
+
+	// sleep and now are replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+	now   func() time.Time
+}
+
+// RateLimitError reports a request refused by a rate limit.
+type RateLimitError struct {
+	Host string
+	// Reset is when the limit resets, or zero if the server did not say.
+	Reset time.Time
+	// Wait is how long until Reset at the time of the response.
+	Wait time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Reset.IsZero() {
+		return fmt.Sprintf("rate limited by %s; try again later", e.Host)
+	}
+	return fmt.Sprintf("rate limited by %s; the limit resets in %s (at %s)",
+		e.Host, e.Wait.Round(time.Second), e.Reset.Local().Format("15:04:05"))
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	maxRetries := orDefault(t.MaxRetries, defaultMaxRetries)
+
+	for attempt := 0; ; attempt++ {
+		resp, err := base.RoundTrip(req)
+		last := attempt == maxRetries || (req.Body != nil && req.GetBody == nil)
+
+		var wait time.Duration
+		switch {
+		case err != nil:
+			if last || req.Context().Err() != nil {
+				return nil, err
+			}
+			wait = t.backoff(attempt)
+
+		case rateLimited(resp):
+			limit := t.rateLimit(req, resp)
+			if last || limit.Wait > orDefault(t.MaxWait, defaultMaxWait) {
+				resp.Body.Close()
+				return nil, limit
+			}
+			wait = max(limit.Wait, t.backoff(attempt))
+			resp.Body.Close()
+
+		case resp.StatusCode >= 500:
+			if last {
+				return resp, nil
+			}
+			wait = t.backoff(attempt)
+			resp.Body.Close()
+
+		default:
+			return resp, nil
+		}
+
+		if err := t.doSleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// rateLimited reports whether resp was refused by a rate limit. GitHub uses
+// 403 with X-RateLimit-Remaining: 0 as well as 429.
+func rateLimited(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return resp.StatusCode == http.StatusForbidden &&
+		(resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != "")
+}
+
+// rateLimit works out when a rate limit resets, from Retry-After (seconds or
+// an HTTP date) or else X-RateLimit-Reset (Unix seconds).
+func (t *Transport) rateLimit(req *http.Request, resp *http.Response) *RateLimitError {
+	now := t.clock()
+	e := &RateLimitError{Host: req.URL.Host}
+
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			e.Reset = now.Add(time.Duration(secs) * time.Second)
+		} else if at, err := http.ParseTime(v); err == nil {
+			e.Reset = at
+		}
+	}
+	if e.Reset.IsZero() {
+		if v := resp.Header.Get("X-RateLimit-Reset"); v != "" {
+			if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+				e.Reset = time.Unix(secs, 0)
+			}
+		}
+	}
+	if !e.Reset.IsZero() {
+		e.Wait = max(0, e.Reset.Sub(now))
+	}
+	return e
+}
+
+// backoff returns the jittered delay before retry number attempt+1: a random
+// duration between half and all of BaseDelay*2^attempt, capped at MaxDelay.
+func (t *Transport) backoff(attempt int) time.Duration {
+	d := orDefault(t.BaseDelay, defaultBaseDelay) << attempt
+	d = min(d, orDefault(t.MaxDelay, defaultMaxDelay))
+	return d/2 + rand.N(d/2+1)
+}
+
+func (t *Transport) doSleep(ctx context.Context, d time.Duration) error {
+	if t.sleep != nil {
+		return t.sleep(ctx, d)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (t *Transport) clock() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+	return time.Now()
+}
+
+func orDefault[T int | time.Duration](v, def T) T {
+	if v == 0 {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,185 @@
+// ABOUTME: Tests for the retrying HTTP transport.
+// ABOUTME: Runs against httptest servers that fail, rate limit and recover.
+
+package httpretry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client whose transport records its sleeps instead
+// of waiting.
+func newTestClient(now time.Time) (*http.Client, *[]time.Duration) {
+	var sleeps []time.Duration
+	t := &Transport{
+		sleep: func(_ context.Context, d time.Duration) error {
+			sleeps = append(sleeps, d)
+			return nil
+		},
+		now: func() time.Time { return now },
+	}
+	return &http.Client{Transport: t}, &sleeps
+}
+
+// sequenceServer answers each request with the next handler, repeating the
+// last one, and counts requests.
+func sequenceServer(t *testing.T, handlers ...http.HandlerFunc) (*httptest.Server, *int32) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1)) - 1
+		handlers[min(n, len(handlers)-1)](w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func status(code int, headers ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i+1 < len(headers); i += 2 {
+			w.Header().Set(headers[i], headers[i+1])
+		}
+		w.WriteHeader(code)
+	}
+}
+
+func TestTransport_RetriesServerErrors(t *testing.T) {
+	srv, calls := sequenceServer(t, status(503), status(502), status(200))
+	client, sleeps := newTestClient(time.Now())
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Errorf("StatusCode = %d, want 200", resp.StatusCode)
+	}
+	if *calls != 3 {
+		t.Errorf("server saw %d requests, want 3", *calls)
+	}
+	if len(*sleeps) != 2 {
+		t.Fatalf("slept %d times, want 2", len(*sleeps))
+	}
+	for i, d := range *sleeps {
+		full := defaultBaseDelay << i
+		if d < full/2 || d > full {
+			t.Errorf("backoff %d = %v, want between %v and %v", i, d, full/2, full)
+		}
+	}
+}
+
+func TestTransport_GivesUpOnServerErrors(t *testing.T) {
+	srv, calls := sequenceServer(t, status(500))
+	client, _ := newTestClient(time.Now())
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 500 {
+		t.Errorf("StatusCode = %d, want 500", resp.StatusCode)
+	}
+	if want := int32(defaultMaxRetries + 1); *calls != want {
+		t.Errorf("server saw %d requests, want %d", *calls, want)
+	}
+}
+
+func TestTransport_HonoursRetryAfter(t *testing.T) {
+	srv, _ := sequenceServer(t, status(429, "Retry-After", "3"), status(200))
+	client, sleeps := newTestClient(time.Now())
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if len(*sleeps) != 1 || (*sleeps)[0] < 3*time.Second {
+		t.Errorf("sleeps = %v, want one of at least 3s", *sleeps)
+	}
+}
+
+func TestTransport_LongRateLimitFails(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	reset := now.Add(20 * time.Minute)
+	srv, calls := sequenceServer(t, status(403,
+		"X-RateLimit-Remaining", "0",
+		"X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10),
+	))
+	client, sleeps := newTestClient(now)
+
+	_, err := client.Get(srv.URL)
+	var limit *RateLimitError
+	if !errors.As(err, &limit) {
+		t.Fatalf("Get() error = %v, want a *RateLimitError", err)
+	}
+	if !limit.Reset.Equal(reset) || limit.Wait != 20*time.Minute {
+		t.Errorf("RateLimitError = %+v, want reset at %v in 20m", limit, reset)
+	}
+	if *calls != 1 || len(*sleeps) != 0 {
+		t.Errorf("made %d requests and %d sleeps, want 1 and 0", *calls, len(*sleeps))
+	}
+}
+
+func TestTransport_ForbiddenWithoutRateLimitPassesThrough(t *testing.T) {
+	srv, calls := sequenceServer(t, status(403, "X-RateLimit-Remaining", "42"))
+	client, _ := newTestClient(time.Now())
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 403 || *calls != 1 {
+		t.Errorf("got status %d after %d requests, want 403 after 1", resp.StatusCode, *calls)
+	}
+}
+
+func TestTransport_RetriesNetworkErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	client, sleeps := newTestClient(time.Now())
+	if _, err := client.Get(url); err == nil {
+		t.Fatal("Get() error = nil, want connection error")
+	}
+	if len(*sleeps) != defaultMaxRetries {
+		t.Errorf("slept %d times, want %d", len(*sleeps), defaultMaxRetries)
+	}
+}
+
+func TestRateLimitError(t *testing.T) {
+	err := &RateLimitError{Host: "api.github.com", Reset: time.Now().Add(90 * time.Second), Wait: 90 * time.Second}
+	if got := err.Error(); !strings.HasPrefix(got, "rate limited by api.github.com; the limit resets in 1m30s") {
+		t.Errorf("Error() = %q, want the wait until reset", got)
+	}
+}
+
+func TestDo_UnwrapsRateLimitError(t *testing.T) {
+	srv, _ := sequenceServer(t, status(429, "Retry-After", "3600"))
+	client, _ := newTestClient(time.Now())
+	saved := Client
+	Client = client
+	defer func() { Client = saved }()
+
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	_, err = Do(req)
+	if _, ok := err.(*RateLimitError); !ok {
+		t.Errorf("Do() error = %#v, want a bare *RateLimitError", err)
+	}
+}
@@ -4,16 +4,28 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bartriepe/my-docs/cmd"
 	"github.com/bartriepe/my-docs/config"
 	"github.com/bartriepe/my-docs/cratesio"
+	"github.com/bartriepe/my-docs/forge"
+	"github.com/bartriepe/my-docs/gitea"
+	"github.com/bartriepe/my-docs/githttp"
 	"github.com/bartriepe/my-docs/github"
+	"github.com/bartriepe/my-docs/gitlab"
 	"github.com/bartriepe/my-docs/grepapp"
+	"github.com/bartriepe/my-docs/repospec"
+	"github.com/bartriepe/my-docs/snapshot"
+	"github.com/bartriepe/my-docs/textdiff"
 )
 
 func main() {
@@ -25,6 +37,12 @@ func main() {
 	command := os.Args[1]
 	args := os.Args[2:]
 
+	// GITHUB_TOKEN and GH_TOKEN override a token from the config file.
+	switch command {
+	case "search", "cat", "ls", "tree", "releases", "log", "diff", "issues", "issue", "rust":
+		github.SetToken(loadConfig().GitHubToken)
+	}
+
 	switch command {
 	case "find":
 		runFind(args)
@@ -32,6 +50,20 @@ func main() {
 		runSearch(args)
 	case "cat":
 		runCat(args)
+	case "ls":
+		runLs(args)
+	case "tree":
+		runTree(args)
+	case "releases":
+		runReleases(args)
+	case "log":
+		runLog(args)
+	case "diff":
+		runDiff(args)
+	case "issues":
+		runIssues(args)
+	case "issue":
+		runIssue(args)
 	case "rust":
 		runRust(args)
 	case "install":
@@ -53,11 +85,71 @@ Usage:
 
 Commands:
   search [owner/repo] <pattern>  Search repo via grep.app (omit repo to search all)
+                                 Repo may be a list (a/x,b/y) or an org (owner/*);
+                                 owner/repo//subdir searches one directory;
+                                 owner/repo@ref searches a tag, branch or commit locally;
+                                 host/owner/repo searches a GitLab or Gitea repo locally
     --limit N                    Max results to show (default: 15)
     --offset N                   Skip first N results (for pagination)
-  cat <owner/repo> <path>        Fetch and display file from GitHub
+    --lang L                     Only search files in language L (e.g. Go, Markdown)
+    --path P                     Only search under path P (e.g. docs/)
+    --facets                     Show match counts by repo, language and path instead
+    -F, --fixed-strings          Treat the pattern as a literal string, not a regex
+    -s, --case-sensitive         Match case exactly (default: case-insensitive)
+    -w, --word                   Only match whole words
+    -A N, -B N, -C N             Show N lines after, before or around each match
+    -l, --files-with-matches     Only list the files that match
+    -c, --count                  List matching files with their match counts
+    --group                      Print each file once with its matches beneath it
+    --exclude GLOB               Skip matching paths, e.g. 'vendor/' or '*.pb.go' (repeatable)
+    --no-vendor                  Skip vendored dependencies and generated code
+    --no-tests                   Skip tests and test fixtures
+    --vendor, --tests            Include them again when the config file excludes them
+    --no-dedupe                  Show every copy of a match found in forks and vendored copies
+    --local                      Search a downloaded snapshot instead of grep.app; implied by
+                                 owner/repo@ref and used when grep.app does not index a repo
+    --column                     Show the column of the first match on each line
+    --color WHEN                 Highlight matches: auto (default, on a terminal), always, never
+  cat <owner/repo> <path>        Fetch and display file from GitHub, or from GitLab or
+                                 Gitea with gitlab.com/group/project, codeberg.org/owner/repo
+                                 or a configured host;
+                                 owner/repo@ref reads a tag, branch or commit;
+                                 owner/repo//subdir makes path relative to subdir;
+                                 path:400-460 prints only those lines
+    --lines START:END            Print only lines START to END (either may be omitted)
+    --head N, --tail N           Print only the first or last N lines
+    -n, --number                 Number lines as search does
+    -r, --recursive              Print every file under a directory (implied by a trailing /)
+    --glob GLOB                  With -r, only print matching files, e.g. '*.md' (repeatable)
+    --max-bytes N                With -r, skip files once the bundle would exceed N bytes
+  ls <owner/repo> [dir]          List a directory's files and subdirectories with sizes
+  tree <owner/repo> [dir]        Show the files under a directory as an indented tree
+    --depth N                    Only show N levels (default: all)
+    --glob GLOB                  Only show matching files, e.g. '*.md' (repeatable)
+  releases <owner/repo>          Show release notes, newest first, or the versions in
+                                 CHANGELOG.md or CHANGES.md when there are no releases
+    --since V, --until V         Only show versions from V or up to V (inclusive)
+    --limit N                    Max versions to show (default: 10, or all in a range)
+  log <owner/repo> [path]        List the commits touching a file or directory, newest first;
+                                 each full SHA works as owner/repo@SHA in cat
+    --limit N                    Max commits to show (default: 10)
+    --since DATE                 Only show commits since DATE (YYYY-MM-DD)
+    --patch                      Show each commit's diff of the path
+  diff <owner/repo> <path> <from> <to>
+                                 Show a unified diff of a file between two refs
+    -U N                         Show N lines of context (default: 3)
+    --stat                       Compare a directory instead (path optional) and list the
+                                 files added (A), removed (D) and modified (M)
+  issues <owner/repo> <query>    Search a GitHub repo's issues and pull requests
+    --state S                    Only show open or closed ones
+    --type T                     Only show issues (issue), pull requests (pr) or, with a
+                                 token, discussions (discussion) instead
+    --limit N                    Max results to show, up to 1000 (default: 10)
+  issue <owner/repo> <number>    Show an issue, pull request or discussion with all its comments
   find <query>                   Search for repos by name
+    --lang L, --path P           Only count matches in language L or under path P
   rust <crate> <symbol>          Look up a Rust crate symbol and show its source
+    -F, -s, -w                   Same matching modes as search
   install                        Install instructions into ~/.claude/CLAUDE.md`)
 }
 
@@ -88,15 +180,25 @@ func saveConfig(cfg *config.Config) {
 }
 
 func runFind(args []string) {
-	if len(args) != 1 {
-		fmt.Fprintln(os.Stderr, "usage: my-docs find <query>")
-		os.Exit(1)
+	var q grepapp.Query
+
+	var positionalArgs []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case stringFlag(args, &i, "--lang", &q.Lang):
+		case stringFlag(args, &i, "--path", &q.Path):
+		default:
+			positionalArgs = append(positionalArgs, args[i])
+		}
 	}
-	resp, err := grepapp.Search(args[0], "")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+
+	if len(positionalArgs) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: my-docs find <query> [--lang L] [--path P]")
 		os.Exit(1)
 	}
+	q.Pattern = positionalArgs[0]
+
+	resp := searchOrExit(q)
 	if len(resp.Facets.Repo.Buckets) == 0 {
 		fmt.Println("No repositories found")
 		return
@@ -106,122 +208,1194 @@ func runFind(args []string) {
 	}
 }
 
+// searchOrExit runs q for a single page and, when language or path filters
+// leave no hits, validates them so typos get suggestions.
+func searchOrExit(q grepapp.Query) *grepapp.Response {
+	resp, err := grepapp.Search(q)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(resp.Hits.Hits) > 0 || (q.Lang == "" && q.Path == "") {
+		return resp
+	}
+
+	resolved := resolveFiltersOrExit(q)
+	if resolved == q {
+		return resp
+	}
+	resp, err = grepapp.Search(resolved)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	return resp
+}
+
+// resolveFiltersOrExit corrects q's language and path filters against the
+// facets of the unfiltered query, exiting with suggestions if they are unknown.
+func resolveFiltersOrExit(q grepapp.Query) grepapp.Query {
+	resolved, err := grepapp.ResolveFilters(q)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	return resolved
+}
+
+// intFlag is stringFlag for integer values, exiting on a malformed number.
+func intFlag(args []string, i *int, name string, dst *int) bool {
+	var value string
+	if !stringFlag(args, i, name, &value) {
+		return false
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		fmt.Fprintf(os.Stderr, "error: invalid value %q for %s\n", value, name)
+		os.Exit(1)
+	}
+	*dst = n
+	return true
+}
+
+// stringFlag consumes "name value" or "name=value" at args[*i] into dst.
+func stringFlag(args []string, i *int, name string, dst *string) bool {
+	arg := args[*i]
+	if arg == name && *i+1 < len(args) {
+		*dst = args[*i+1]
+		*i++
+		return true
+	}
+	if value, ok := strings.CutPrefix(arg, name+"="); ok {
+		*dst = value
+		return true
+	}
+	return false
+}
+
 func runSearch(args []string) {
 	// Default pagination
 	limit := 15
 	offset := 0
+	var lang, path string
+	var fixed, caseSensitive, words bool
+	var before, after, around int
+	var filesOnly, counts, group bool
+	var excludes []string
+	var noVendor, noTests, withVendor, withTests bool
+	noDedupe := false
+	local := false
+	column := false
+	color := "auto"
+	facets := false
 
 	// Parse flags manually (before positional args)
 	var positionalArgs []string
+	var glob string
 	for i := 0; i < len(args); i++ {
 		switch {
-		case args[i] == "--limit" && i+1 < len(args):
-			fmt.Sscanf(args[i+1], "%d", &limit)
-			i++
-		case args[i] == "--offset" && i+1 < len(args):
-			fmt.Sscanf(args[i+1], "%d", &offset)
-			i++
-		case strings.HasPrefix(args[i], "--limit="):
-			fmt.Sscanf(args[i], "--limit=%d", &limit)
-		case strings.HasPrefix(args[i], "--offset="):
-			fmt.Sscanf(args[i], "--offset=%d", &offset)
+		case intFlag(args, &i, "--limit", &limit):
+		case intFlag(args, &i, "--offset", &offset):
+		case stringFlag(args, &i, "--lang", &lang):
+		case stringFlag(args, &i, "--path", &path):
+		case args[i] == "--facets":
+			facets = true
+		case args[i] == "--fixed-strings" || args[i] == "-F":
+			fixed = true
+		case args[i] == "--case-sensitive" || args[i] == "-s":
+			caseSensitive = true
+		case args[i] == "--word" || args[i] == "-w":
+			words = true
+		case intFlag(args, &i, "-A", &after):
+		case intFlag(args, &i, "-B", &before):
+		case intFlag(args, &i, "-C", &around):
+		case args[i] == "--files-with-matches" || args[i] == "-l":
+			filesOnly = true
+		case args[i] == "--count" || args[i] == "-c":
+			counts = true
+		case args[i] == "--group":
+			group = true
+		case stringFlag(args, &i, "--exclude", &glob):
+			excludes = append(excludes, glob)
+		case args[i] == "--no-vendor":
+			noVendor = true
+		case args[i] == "--no-tests":
+			noTests = true
+		case args[i] == "--vendor":
+			withVendor = true
+		case args[i] == "--tests":
+			withTests = true
+		case args[i] == "--no-dedupe":
+			noDedupe = true
+		case args[i] == "--local":
+			local = true
+		case args[i] == "--column":
+			column = true
+		case stringFlag(args, &i, "--color", &color):
 		default:
 			positionalArgs = append(positionalArgs, args[i])
 		}
 	}
 
-	if len(positionalArgs) < 1 || len(positionalArgs) > 2 {
-		fmt.Fprintln(os.Stderr, "usage: my-docs search [owner/repo] <pattern> [--limit N] [--offset N]")
+	if len(positionalArgs) < 1 || len(positionalArgs) > 2 || limit <= 0 {
+		fmt.Fprintln(os.Stderr, "usage: my-docs search [owner/repo] <pattern> [--limit N] [--offset N] [--lang L] [--path P] [--facets] [-F] [-s] [-w] [-A N] [-B N] [-C N] [-l | --count | --group] [--exclude GLOB] [--no-vendor] [--no-tests] [--no-dedupe] [--local] [--column] [--color auto|always|never]")
 		os.Exit(1)
 	}
+	// -A and -B take precedence over -C, as in grep.
+	if before == 0 {
+		before = around
+	}
+	if after == 0 {
+		after = around
+	}
 
-	var repo, pattern string
+	var repoArg, pattern string
 	if len(positionalArgs) == 1 {
 		// No repo specified, search across all repos
 		pattern = positionalArgs[0]
-		repo = ""
 	} else {
-		// Repo specified in owner/repo format
-		repo = positionalArgs[0]
-		if !strings.Contains(repo, "/") {
-			fmt.Fprintf(os.Stderr, "error: invalid repo format %q: must be owner/repo\n", repo)
-			os.Exit(1)
-		}
+		// Repos specified as owner/repo, comma-separated, or owner/*
+		repoArg = positionalArgs[0]
 		pattern = positionalArgs[1]
 	}
 
-	resp, err := grepapp.Search(pattern, repo)
+	q := grepapp.Query{
+		Pattern:       pattern,
+		Lang:          lang,
+		Path:          path,
+		FixedStrings:  fixed,
+		CaseSensitive: caseSensitive,
+		Words:         words,
+	}
+	if err := q.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Exclusions from the config file apply unless --vendor/--tests turn the
+	// presets back on.
+	cfg := loadConfig()
+	excludes = append(excludes, cfg.Search.Exclude...)
+	if (noVendor || cfg.Search.NoVendor) && !withVendor {
+		excludes = append(excludes, cmd.VendorGlobs...)
+	}
+	if (noTests || cfg.Search.NoTests) && !withTests {
+		excludes = append(excludes, cmd.TestGlobs...)
+	}
+	exclude, err := cmd.NewGlobSet(excludes)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
-	if len(resp.Hits.Hits) == 0 {
+
+	specs, named := resolveReposOrExit(q, repoArg)
+	if repoArg != "" && len(specs) == 0 {
 		fmt.Println("No matches found")
 		return
 	}
+	// grep.app only indexes default branches on GitHub, so a ref or another
+	// host means searching a snapshot locally.
+	for _, spec := range specs {
+		if spec.Ref != "" || spec.Host != "" {
+			local = true
+		}
+	}
+	if local && len(specs) == 0 {
+		fmt.Fprintln(os.Stderr, "error: --local needs the repos to search")
+		os.Exit(1)
+	}
+	if local && facets {
+		fmt.Fprintln(os.Stderr, "error: --facets needs grep.app and cannot be used with --local, @ref or repos outside GitHub")
+		os.Exit(1)
+	}
+	showRepo := len(specs) != 1
+	opts := cmd.FormatOptions{ShowRepo: showRepo, Column: column, Color: useColor(color), Specs: make(map[string]repospec.Spec)}
+	for _, spec := range specs {
+		opts.Specs[strings.ToLower(spec.Name())] = spec
+	}
+
+	// Each repo gets its own query, scoped to the repo and to its
+	// subdirectory when the spec names one.
+	queries := []grepapp.Query{q}
+	if len(specs) > 0 {
+		queries = nil
+		for _, spec := range specs {
+			rq := q
+			rq.Repo = spec.Repo
+			if spec.Subdir != "" {
+				rq.Path = spec.Join(q.Path)
+			}
+			queries = append(queries, rq)
+		}
+	}
+
+	if facets {
+		var resps []*grepapp.Response
+		for _, rq := range queries {
+			resps = append(resps, searchOrExit(rq))
+		}
+		if len(resps) == 1 {
+			fmt.Print(cmd.FormatFacets(resps[0]))
+			return
+		}
+		fmt.Print(cmd.FormatFacets(grepapp.MergeFacets(resps)))
+		return
+	}
 
-	// Collect all match lines
-	type matchLine struct {
-		path string
-		line int
-		text string
+	// Fetch pages until the requested window (plus one result, to know
+	// whether another page exists) is filled. Results are files for -l and
+	// --count, and lines otherwise.
+	want := offset + limit + 1
+	withContext := before > 0 || after > 0
+	var source pagedSource
+	var collector *cmd.Collector
+	var allMatches []cmd.MatchLine
+	var allFiles []cmd.FileMatch
+	collect := func(src pagedSource) (int, error) {
+		source = src
+		collector = &cmd.Collector{
+			Source:      source,
+			MatchedOnly: withContext,
+			Exclude:     exclude,
+			// Forks and vendored copies only pile up across repos.
+			Dedupe: showRepo && !noDedupe,
+			Rank: func(repo string) int {
+				return source.RepoCounts()[repo]
+			},
+		}
+		var err error
+		if filesOnly || counts {
+			allFiles, err = collector.Files(want)
+			return len(allFiles), err
+		}
+		allMatches, err = collector.Lines(want)
+		return len(allMatches), err
 	}
-	var allMatches []matchLine
-	for _, hit := range resp.Hits.Hits {
-		matches := grepapp.ExtractText(hit.Content.Snippet)
-		for _, m := range matches {
-			allMatches = append(allMatches, matchLine{hit.Path, m.Line, m.Text})
+
+	var total int
+	if local {
+		total, err = collect(localSourceOrExit(q, specs, providersOrExit(specs), queries, max(before, after)))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		total, err = collect(grepSource(queries))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if total == 0 {
+			resolved := make([]grepapp.Query, len(queries))
+			changed := false
+			for i, rq := range queries {
+				resolved[i] = rq
+				if rq.Lang != "" || rq.Path != "" {
+					resolved[i] = resolveFiltersOrExit(rq)
+					changed = changed || resolved[i] != rq
+				}
+			}
+			if changed {
+				total, err = collect(grepSource(resolved))
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error: %v\n", err)
+					os.Exit(1)
+				}
+			}
 		}
+		if total == 0 && len(specs) > 0 {
+			// grep.app only indexes a subset of repos; search the ones it
+			// does not know about in a local snapshot instead.
+			var unindexed []repospec.Spec
+			var unindexedQueries []grepapp.Query
+			for i, spec := range specs {
+				indexed, err := grepapp.Indexed(spec.Repo)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error: %v\n", err)
+					os.Exit(1)
+				}
+				if !indexed {
+					unindexed = append(unindexed, spec)
+					unindexedQueries = append(unindexedQueries, queries[i])
+				}
+			}
+			if len(unindexed) > 0 {
+				for _, spec := range unindexed {
+					fmt.Fprintf(os.Stderr, "%s is not indexed by grep.app; searching a local snapshot\n", spec.Repo)
+				}
+				local = true
+				total, err = collect(localSourceOrExit(q, unindexed, providersOrExit(unindexed), unindexedQueries, max(before, after)))
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+		}
+	}
+	if total == 0 {
+		fmt.Println("No matches found")
+		return
+	}
+	if !local {
+		// Local hits carry the searched ref, which need not be the default.
+		rememberBranches(collector.Hits, specs[:named])
 	}
 
 	// Apply offset and limit
-	total := len(allMatches)
 	if offset >= total {
 		fmt.Println("No more results")
 		return
 	}
-	end := offset + limit
-	if end > total {
-		end = total
+	end := min(offset+limit, total)
+
+	switch {
+	case filesOnly:
+		fmt.Print(cmd.FormatFiles(allFiles[offset:end], opts))
+	case counts:
+		fmt.Print(cmd.FormatCounts(allFiles[offset:end], opts))
+	case group:
+		fmt.Print(cmd.FormatGrouped(allMatches[offset:end], opts))
+	case withContext:
+		files := cmd.BuildFileContexts(allMatches[offset:end], collector.Hits)
+		for _, fc := range files {
+			// Local hits already carry every context line the file has.
+			if local || !fc.Missing(before, after) {
+				continue
+			}
+			// The snippet does not cover the requested context; read the file.
+			content, err := fetchHitFile(repospec.Spec{Repo: fc.Repo}, github.Provider{}, fc.Branch, fc.Path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+				continue
+			}
+			fc.SetContent(content)
+		}
+		fmt.Print(cmd.FormatContext(files, before, after, opts))
+	default:
+		fmt.Print(cmd.FormatLines(allMatches[offset:end], opts))
+	}
+
+	if total > end {
+		fmt.Print(cmd.FormatPageFooter(offset, end, source.Total()))
+	}
+}
+
+// useColor resolves a --color setting; "auto" colors only when stdout is a
+// terminal and NO_COLOR is unset.
+func useColor(setting string) bool {
+	switch setting {
+	case "always":
+		return true
+	case "never":
+		return false
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false
+		}
+		info, err := os.Stdout.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0
+	default:
+		fmt.Fprintf(os.Stderr, "error: invalid value %q for --color: must be auto, always or never\n", setting)
+		os.Exit(1)
+		return false
+	}
+}
+
+// grepSource pages through grep.app results for one query per repo.
+func grepSource(queries []grepapp.Query) pagedSource {
+	if len(queries) > 1 {
+		return grepapp.NewMultiPager(queries)
 	}
-	displayed := allMatches[offset:end]
+	return grepapp.NewPager(queries[0])
+}
 
-	for _, m := range displayed {
-		fmt.Printf("%s:%d: %s\n", m.path, m.line, m.text)
+// localSourceOrExit searches snapshots of the repos in specs, each read
+// through its provider and scoped to the path of its query, for q's pattern.
+// context is how many lines around each match the hits carry.
+func localSourceOrExit(q grepapp.Query, specs []repospec.Spec, providers []forge.Provider, queries []grepapp.Query, context int) pagedSource {
+	if q.Lang != "" {
+		fmt.Fprintln(os.Stderr, "warning: --lang is not supported by local search and is ignored")
+	}
+	re, err := q.Regexp()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	cache, err := snapshot.DefaultCache()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Show remaining count
-	remaining := total - end
-	if remaining > 0 {
-		fmt.Printf("\n... and %d more results (use --offset %d to see next page)\n", remaining, end)
+	var targets []snapshot.Target
+	for i, spec := range specs {
+		targets = append(targets, snapshot.Target{
+			Host:     spec.Host,
+			Repo:     spec.Repo,
+			Ref:      specRef(spec, providers[i]),
+			Path:     queries[i].Path,
+			Provider: providers[i],
+		})
+	}
+	return snapshot.NewSource(cache, re, targets, context)
+}
+
+// pagedSource is a stream of search hits that knows the total match count
+// and the per-repo counts of the repo facet.
+type pagedSource interface {
+	cmd.HitSource
+	Total() int
+	RepoCounts() map[string]int
+}
+
+// resolveReposOrExit expands a repo argument into the repos to search. Org
+// wildcards become the org's repos that match q, most matches first. The
+// repos named explicitly come first; named is how many there are. An empty
+// argument means all repos and yields no entries.
+func resolveReposOrExit(q grepapp.Query, arg string) (specs []repospec.Spec, named int) {
+	if arg == "" {
+		return nil, 0
+	}
+	explicit, orgs, err := cmd.ParseRepoList(arg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	seen := make(map[string]bool)
+	add := func(spec repospec.Spec) {
+		if !seen[strings.ToLower(spec.Name())] {
+			seen[strings.ToLower(spec.Name())] = true
+			specs = append(specs, spec)
+		}
+	}
+	for _, spec := range explicit {
+		add(spec)
+	}
+	named = len(specs)
+	for _, owner := range orgs {
+		orgRepos, err := grepapp.OrgRepos(q, owner)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, repo := range orgRepos {
+			add(repospec.Spec{Repo: repo})
+		}
+	}
+	return specs, named
+}
+
+// parseSpecOrExit parses a [host/]owner/repo[@ref][//subdir] argument.
+func parseSpecOrExit(arg string) repospec.Spec {
+	spec, err := repospec.Parse(arg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	return spec
+}
+
+// providerOrExit returns the provider for the host a spec names.
+func providerOrExit(spec repospec.Spec) forge.Provider {
+	p, err := providerFor(loadConfig(), spec.Host)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	return p
+}
+
+// providersOrExit returns the provider for each spec, one per host, so that
+// per-client state such as githttp's snapshots is shared.
+func providersOrExit(specs []repospec.Spec) []forge.Provider {
+	byHost := make(map[string]forge.Provider)
+	var providers []forge.Provider
+	for _, spec := range specs {
+		p, ok := byHost[spec.Host]
+		if !ok {
+			p = providerOrExit(spec)
+			byHost[spec.Host] = p
+		}
+		providers = append(providers, p)
+	}
+	return providers
+}
+
+// publicHosts are the forges that work without an entry in the config file.
+var publicHosts = map[string]string{
+	"gitlab.com":   "gitlab",
+	"codeberg.org": "gitea",
+}
+
+// giteaHosts returns the hosts known to run Gitea or Forgejo.
+func giteaHosts(cfg *config.Config) map[string]bool {
+	hosts := make(map[string]bool)
+	for host, typ := range publicHosts {
+		hosts[host] = typ == "gitea"
+	}
+	for host, h := range cfg.Hosts {
+		hosts[host] = h.Type == "gitea" || h.Type == "forgejo"
+	}
+	return hosts
+}
+
+// providerFor returns GitHub for an empty host, and otherwise the host's
+// entry in the config file or its publicHosts type. GITLAB_TOKEN overrides
+// the configured token for gitlab.com.
+func providerFor(cfg *config.Config, host string) (forge.Provider, error) {
+	if host == "" {
+		return github.Provider{}, nil
+	}
+	h, ok := cfg.Hosts[host]
+	if !ok && publicHosts[host] != "" {
+		h, ok = config.Host{Type: publicHosts[host]}, true
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown host %s: add it to \"hosts\" in the config file", host)
+	}
+
+	baseURL := h.URL
+	if baseURL == "" {
+		baseURL = "https://" + host
+	}
+	switch h.Type {
+	case "gitlab":
+		token := h.Token
+		if env := os.Getenv("GITLAB_TOKEN"); env != "" && host == "gitlab.com" {
+			token = env
+		}
+		return gitlab.New(baseURL, token), nil
+	case "gitea", "forgejo":
+		return gitea.New(baseURL, h.Token), nil
+	case "git":
+		return githttp.New(baseURL, h.Token), nil
+	}
+	return nil, fmt.Errorf("host %s has unsupported type %q: must be gitlab, gitea, forgejo or git", host, h.Type)
+}
+
+// fetchHitFile reads a file at a branch, tag or commit, or from the default
+// branch when ref is empty.
+func fetchHitFile(spec repospec.Spec, provider forge.Provider, ref, path string) (string, error) {
+	if ref == "" {
+		ref = defaultBranch(spec, provider)
+	}
+	return forge.ReadFile(provider, spec.Repo, ref, path)
+}
+
+// specRef returns the ref a spec names, or its repo's default branch.
+func specRef(spec repospec.Spec, provider forge.Provider) string {
+	if spec.Ref != "" {
+		return spec.Ref
+	}
+	return defaultBranch(spec, provider)
+}
+
+// defaultBranch returns the default branch of the spec's repo, from the
+// config cache while it is fresh and from the host's API otherwise. If the
+// lookup fails, for example on GitHub's low anonymous rate limit, it returns
+// HEAD, which GitHub, GitLab and Gitea all resolve to the default branch.
+func defaultBranch(spec repospec.Spec, provider forge.Provider) string {
+	cfg := loadConfig()
+	if branch, ok := cfg.DefaultBranch(spec.Name(), time.Now()); ok {
+		return branch
+	}
+	branch, err := provider.DefaultBranch(spec.Repo)
+	if err != nil {
+		return "HEAD"
+	}
+	cfg.SetDefaultBranch(spec.Name(), branch, time.Now())
+	saveConfig(cfg)
+	return branch
+}
+
+// rememberBranches caches the default branches of the named repos that have
+// hits. grep.app indexes default branches, so each hit's Branch is one. Repos
+// found through wildcards or searches of all repos are not cached, since
+// they are unlikely to be read again. The config is loaded afresh, since
+// looking up a default branch may have saved it since the command began.
+func rememberBranches(hits []grepapp.Hit, named []repospec.Spec) {
+	wanted := make(map[string]bool)
+	for _, spec := range named {
+		if spec.Host == "" {
+			wanted[strings.ToLower(spec.Repo)] = true
+		}
+	}
+	cfg := loadConfig()
+	now := time.Now()
+	changed := false
+	for _, hit := range hits {
+		if hit.Branch == "" || !wanted[strings.ToLower(hit.Repo)] {
+			continue
+		}
+		if branch, ok := cfg.DefaultBranch(hit.Repo, now); ok && branch == hit.Branch {
+			continue
+		}
+		cfg.SetDefaultBranch(hit.Repo, hit.Branch, now)
+		changed = true
+	}
+	if changed {
+		saveConfig(cfg)
 	}
 }
 
 func runCat(args []string) {
+	var slice cmd.Slice
+	var lines string
+	var recursive bool
+	var globs []string
+	var maxBytes int
+
+	var positionalArgs []string
+	var glob string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-r" || args[i] == "--recursive":
+			recursive = true
+		case stringFlag(args, &i, "--glob", &glob):
+			globs = append(globs, glob)
+		case intFlag(args, &i, "--max-bytes", &maxBytes):
+		case stringFlag(args, &i, "--lines", &lines):
+		case intFlag(args, &i, "--head", &slice.Head):
+		case intFlag(args, &i, "--tail", &slice.Tail):
+		case args[i] == "-n" || args[i] == "--number":
+			slice.Number = true
+		default:
+			positionalArgs = append(positionalArgs, args[i])
+		}
+	}
+
+	if len(positionalArgs) == 1 && recursive {
+		positionalArgs = append(positionalArgs, "")
+	}
+	if len(positionalArgs) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: my-docs cat <owner/repo[@ref][//subdir]> <path[:START-END]> [--lines START:END] [--head N] [--tail N] [-n]")
+		fmt.Fprintln(os.Stderr, "       my-docs cat <owner/repo[@ref][//subdir]> <dir/> [-r] [--glob GLOB] [--max-bytes N]")
+		os.Exit(1)
+	}
+	spec := parseSpecOrExit(positionalArgs[0])
+	provider := providerOrExit(spec)
+	ref := specRef(spec, provider)
+
+	if recursive || positionalArgs[1] == "" || strings.HasSuffix(positionalArgs[1], "/") {
+		if !slice.Whole() || lines != "" {
+			fmt.Fprintln(os.Stderr, "error: line ranges, --head, --tail and -n apply to a single file, not a directory")
+			os.Exit(1)
+		}
+		catDir(spec, provider, ref, positionalArgs[1], globs, int64(maxBytes))
+		return
+	}
+
+	path, r, ranged, err := cmd.ParsePathRange(positionalArgs[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if lines != "" {
+		if ranged {
+			fmt.Fprintln(os.Stderr, "error: give a line range either after the path or with --lines, not both")
+			os.Exit(1)
+		}
+		if r, err = cmd.ParseLineRange(lines); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	slice.Lines = r
+
+	body, err := provider.OpenFile(spec.Repo, ref, spec.Join(path))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	defer body.Close()
+
+	if slice.Whole() {
+		_, err = io.Copy(os.Stdout, body)
+	} else {
+		err = cmd.WriteSlice(os.Stdout, body, slice)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// catDir prints the files under dir as one bundle, fetching them
+// concurrently.
+func catDir(spec repospec.Spec, provider forge.Provider, ref, dir string, globs []string, maxBytes int64) {
+	include, err := cmd.NewGlobSet(globs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	files, skipped := cmd.PlanBundle(listTreeOrExit(spec, provider, ref, dir), include, maxBytes)
+	if len(files) == 0 && len(skipped) == 0 {
+		fmt.Println("No matching files")
+		return
+	}
+
+	// Listing paths are relative to dir; show them relative to the spec's
+	// subdirectory so each can be passed to cat on its own.
+	display := func(entries []forge.TreeEntry) []string {
+		var paths []string
+		for _, e := range entries {
+			paths = append(paths, path.Join(dir, e.Path))
+		}
+		return paths
+	}
+	bundle, late := cmd.FetchBundle(display(files), cmd.BundleWorkers, maxBytes, func(file string) (string, error) {
+		return forge.ReadFile(provider, spec.Repo, ref, spec.Join(file))
+	})
+	fmt.Print(cmd.FormatBundle(bundle, append(display(skipped), late...), maxBytes))
+}
+
+func runLs(args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		fmt.Fprintln(os.Stderr, "usage: my-docs ls <owner/repo[@ref][//subdir]> [dir]")
+		os.Exit(1)
+	}
+	dir := ""
+	if len(args) == 2 {
+		dir = args[1]
+	}
+	spec := parseSpecOrExit(args[0])
+	provider := providerOrExit(spec)
+	fmt.Print(cmd.FormatLs(listTreeOrExit(spec, provider, specRef(spec, provider), dir)))
+}
+
+func runTree(args []string) {
+	var depth int
+	var globs []string
+
+	var positionalArgs []string
+	var glob string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case intFlag(args, &i, "--depth", &depth):
+		case stringFlag(args, &i, "--glob", &glob):
+			globs = append(globs, glob)
+		default:
+			positionalArgs = append(positionalArgs, args[i])
+		}
+	}
+
+	if len(positionalArgs) < 1 || len(positionalArgs) > 2 {
+		fmt.Fprintln(os.Stderr, "usage: my-docs tree <owner/repo[@ref][//subdir]> [dir] [--depth N] [--glob GLOB]")
+		os.Exit(1)
+	}
+	include, err := cmd.NewGlobSet(globs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	dir := ""
+	if len(positionalArgs) == 2 {
+		dir = positionalArgs[1]
+	}
+	spec := parseSpecOrExit(positionalArgs[0])
+	provider := providerOrExit(spec)
+	fmt.Print(cmd.FormatTree(listTreeOrExit(spec, provider, specRef(spec, provider), dir), depth, include))
+}
+
+// listTreeOrExit lists the entries under dir at ref, where dir is relative
+// to the spec's subdirectory, with paths relative to dir.
+func listTreeOrExit(spec repospec.Spec, provider forge.Provider, ref, dir string) []forge.TreeEntry {
+	tree, err := provider.Tree(spec.Repo, ref)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if tree.Truncated {
+		fmt.Fprintln(os.Stderr, "warning: the repository is too large to list in full; some files are missing")
+	}
+
+	entries, ok := cmd.Subtree(tree.Entries, spec.Join(dir))
+	if !ok {
+		fmt.Fprintf(os.Stderr, "error: no directory %q in %s\n", strings.Trim(spec.Join(dir), "/"), spec)
+		os.Exit(1)
+	}
+	return entries
+}
+
+// maxRangeReleases is how many releases releases reads to find a version
+// range.
+const maxRangeReleases = 1000
+
+func runReleases(args []string) {
+	var since, until string
+	limit := 0
+
+	var positionalArgs []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case stringFlag(args, &i, "--since", &since):
+		case stringFlag(args, &i, "--until", &until):
+		case intFlag(args, &i, "--limit", &limit):
+		default:
+			positionalArgs = append(positionalArgs, args[i])
+		}
+	}
+
+	if len(positionalArgs) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: my-docs releases <owner/repo[@ref][//subdir]> [--since V] [--until V] [--limit N]")
+		os.Exit(1)
+	}
+	spec := parseSpecOrExit(positionalArgs[0])
+	r, err := cmd.ParseVersionRange(since, until)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	// Without a range, show the latest ten versions; with one, every version
+	// in it unless --limit says otherwise.
+	if limit == 0 && r.Open() {
+		limit = 10
+	}
+	fetchLimit := limit
+	if !r.Open() {
+		fetchLimit = maxRangeReleases
+	}
+	truncate := func(n int) int {
+		if limit > 0 {
+			return min(n, limit)
+		}
+		return n
+	}
+
+	// Stop reading pages once --limit releases are in the range, or once
+	// they are older than --since.
+	found := 0
+	done := func(page []forge.Release) bool {
+		found += len(cmd.FilterReleases(page, r))
+		return (limit > 0 && found >= limit) || r.Passed(page)
+	}
+
+	provider := providerOrExit(spec)
+	if releaser, ok := provider.(forge.Releaser); ok {
+		releases, err := forge.Releases(releaser, spec.Repo, fetchLimit, done)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(releases) > 0 {
+			releases = cmd.FilterReleases(releases, r)
+			if len(releases) == 0 {
+				fmt.Println("No releases in the requested range")
+				return
+			}
+			fmt.Print(cmd.FormatReleases(releases[:truncate(len(releases))]))
+			return
+		}
+	}
+
+	// No releases: fall back to the changelog at the spec's ref.
+	ref := specRef(spec, provider)
+	for _, file := range cmd.ChangelogFiles {
+		content, err := forge.ReadFile(provider, spec.Repo, ref, spec.Join(file))
+		if err != nil {
+			continue
+		}
+		sections := cmd.FilterChangelog(cmd.ParseChangelog(content), r)
+		if len(sections) == 0 {
+			fmt.Printf("No versions in the requested range in %s\n", file)
+			return
+		}
+		fmt.Print(cmd.FormatChangelog(file, sections[:truncate(len(sections))]))
+		return
+	}
+	fmt.Fprintf(os.Stderr, "error: %s has no releases and no %s\n", spec, strings.Join(cmd.ChangelogFiles, " or "))
+	os.Exit(1)
+}
+
+func runLog(args []string) {
+	limit := 10
+	var since string
+	var patch bool
+
+	var positionalArgs []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case intFlag(args, &i, "--limit", &limit):
+		case stringFlag(args, &i, "--since", &since):
+		case args[i] == "--patch" || args[i] == "-p":
+			patch = true
+		default:
+			positionalArgs = append(positionalArgs, args[i])
+		}
+	}
+
+	if len(positionalArgs) < 1 || len(positionalArgs) > 2 || limit == 0 {
+		fmt.Fprintln(os.Stderr, "usage: my-docs log <owner/repo[@ref][//subdir]> [path] [--limit N] [--since DATE] [--patch]")
+		os.Exit(1)
+	}
+	spec := parseSpecOrExit(positionalArgs[0])
+	if spec.Host != "" {
+		fmt.Fprintf(os.Stderr, "error: log reads the GitHub commits API and does not support %s\n", spec.Host)
+		os.Exit(1)
+	}
+	path := ""
+	if len(positionalArgs) == 2 {
+		path = positionalArgs[1]
+	}
+	opts := github.LogOptions{Ref: spec.Ref, Path: strings.Trim(spec.Join(path), "/")}
+	if since != "" {
+		t, err := cmd.ParseSince(since)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Since = t
+	}
+
+	commits, err := github.Commits(spec.Repo, opts, limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(commits) == 0 {
+		fmt.Println("No commits found")
+		return
+	}
+
+	for i, c := range commits {
+		if !patch {
+			fmt.Print(cmd.FormatCommit(c))
+			continue
+		}
+		// The listing leaves out changed files; each commit is fetched for
+		// its patches.
+		full, err := github.FetchCommit(spec.Repo, c.SHA)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Print(cmd.FormatCommit(c))
+		fmt.Print(cmd.FormatPatch(*full, opts.Path))
+	}
+}
+
+func runIssues(args []string) {
+	limit := 10
+	var query github.IssueQuery
+
+	var positionalArgs []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case intFlag(args, &i, "--limit", &limit):
+		case stringFlag(args, &i, "--state", &query.State):
+		case stringFlag(args, &i, "--type", &query.Type):
+		default:
+			positionalArgs = append(positionalArgs, args[i])
+		}
+	}
+
+	if len(positionalArgs) < 2 || limit <= 0 {
+		fmt.Fprintln(os.Stderr, "usage: my-docs issues <owner/repo> <query> [--state open|closed] [--type issue|pr|discussion] [--limit N]")
+		os.Exit(1)
+	}
+	if limit > github.MaxSearchResults {
+		fmt.Fprintf(os.Stderr, "error: invalid --limit %d: GitHub's search returns at most %d results\n", limit, github.MaxSearchResults)
+		os.Exit(1)
+	}
+	if query.State != "" && query.State != "open" && query.State != "closed" {
+		fmt.Fprintf(os.Stderr, "error: invalid --state %q: must be open or closed\n", query.State)
+		os.Exit(1)
+	}
+	if query.Type != "" && query.Type != "issue" && query.Type != "pr" && query.Type != "discussion" {
+		fmt.Fprintf(os.Stderr, "error: invalid --type %q: must be issue, pr or discussion\n", query.Type)
+		os.Exit(1)
+	}
+	spec := githubRepoOrExit("issues", positionalArgs[0])
+	query.Text = strings.Join(positionalArgs[1:], " ")
+
+	search := github.SearchIssues
+	if query.Type == "discussion" {
+		search = github.SearchDiscussions
+	}
+	issues, total, err := search(spec.Repo, query, limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(issues) == 0 {
+		fmt.Println("No issues found")
+		return
+	}
+	fmt.Print(cmd.FormatIssues(issues))
+	switch {
+	case total <= len(issues):
+	case len(issues) == github.MaxSearchResults:
+		fmt.Printf("\n(showing %d of %d; GitHub's search returns no more, so narrow the query)\n", len(issues), total)
+	case len(issues) == limit:
+		fmt.Printf("\n(showing %d of %d; use --limit for more)\n", len(issues), total)
+	default:
+		fmt.Printf("\n(showing %d of %d)\n", len(issues), total)
+	}
+}
+
+func runIssue(args []string) {
 	if len(args) != 2 {
-		fmt.Fprintln(os.Stderr, "usage: my-docs cat <owner/repo> <path>")
+		fmt.Fprintln(os.Stderr, "usage: my-docs issue <owner/repo> <number>")
 		os.Exit(1)
 	}
-	repo := args[0]
-	if !strings.Contains(repo, "/") {
-		fmt.Fprintf(os.Stderr, "error: invalid repo format %q: must be owner/repo\n", repo)
+	spec := githubRepoOrExit("issue", args[0])
+	number, err := strconv.Atoi(strings.TrimPrefix(args[1], "#"))
+	if err != nil || number <= 0 {
+		fmt.Fprintf(os.Stderr, "error: invalid issue number %q\n", args[1])
 		os.Exit(1)
 	}
-	content, err := github.FetchFile(repo, args[1])
+
+	issue, err := github.FetchIssue(spec.Repo, number)
+	if errors.Is(err, github.ErrNotFound) {
+		// Discussions share numbers with issues but are only served by the
+		// GraphQL API. If the number is not one either, report the issue error.
+		discussion, comments, derr := github.FetchDiscussion(spec.Repo, number)
+		if derr == nil {
+			fmt.Print(cmd.FormatThread(*discussion, comments))
+			return
+		}
+		if errors.Is(derr, github.ErrNoToken) {
+			fmt.Fprintf(os.Stderr, "error: #%d of %s is not a public issue or pull request, and discussions can only be read with a token: set GITHUB_TOKEN or GH_TOKEN, or \"github_token\" in the config file\n", number, spec.Repo)
+			os.Exit(1)
+		}
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Print(content)
+	var comments []github.Comment
+	if issue.Comments > 0 {
+		comments, err = github.FetchComments(spec.Repo, number)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	fmt.Print(cmd.FormatThread(*issue, comments))
+}
+
+// githubRepoOrExit parses a plain owner/repo for the commands backed by
+// GitHub's issues API, where refs and subdirectories mean nothing.
+func githubRepoOrExit(command, arg string) repospec.Spec {
+	spec := parseSpecOrExit(arg)
+	if spec.Host != "" {
+		fmt.Fprintf(os.Stderr, "error: %s reads the GitHub issues API and does not support %s\n", command, spec.Host)
+		os.Exit(1)
+	}
+	if spec.Ref != "" || spec.Subdir != "" {
+		fmt.Fprintf(os.Stderr, "error: %s takes a plain owner/repo, without @ref or //subdir\n", command)
+		os.Exit(1)
+	}
+	return spec
+}
+
+func runDiff(args []string) {
+	var stat bool
+	context := 3
+
+	var positionalArgs []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--stat":
+			stat = true
+		case intFlag(args, &i, "-U", &context):
+		default:
+			positionalArgs = append(positionalArgs, args[i])
+		}
+	}
+
+	n := len(positionalArgs)
+	if n != 4 && !(stat && n == 3) {
+		fmt.Fprintln(os.Stderr, "usage: my-docs diff <owner/repo[//subdir]> <path> <from> <to> [-U N]")
+		fmt.Fprintln(os.Stderr, "       my-docs diff <owner/repo[//subdir]> [dir] <from> <to> --stat")
+		os.Exit(1)
+	}
+	spec := parseSpecOrExit(positionalArgs[0])
+	if spec.Ref != "" {
+		fmt.Fprintln(os.Stderr, "error: give the refs to compare as arguments, not with @ref")
+		os.Exit(1)
+	}
+	from, to := positionalArgs[n-2], positionalArgs[n-1]
+	path := ""
+	if n == 4 {
+		path = positionalArgs[1]
+	}
+
+	provider := providerOrExit(spec)
+	if stat {
+		changes := cmd.DiffTrees(listTreeOrExit(spec, provider, from, path), listTreeOrExit(spec, provider, to, path))
+		if len(changes) == 0 {
+			fmt.Printf("No differences between %s and %s\n", from, to)
+			return
+		}
+		fmt.Print(cmd.FormatStat(changes))
+		return
+	}
+
+	file := spec.Join(path)
+	var contents [2]string
+	for i, ref := range []string{from, to} {
+		content, err := forge.ReadFile(provider, spec.Repo, ref, file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		contents[i] = content
+	}
+	if strings.IndexByte(contents[0], 0) != -1 || strings.IndexByte(contents[1], 0) != -1 {
+		if contents[0] != contents[1] {
+			fmt.Printf("Binary file %s differs between %s and %s\n", file, from, to)
+			return
+		}
+	}
+
+	diff := textdiff.Unified("a/"+file+"\t"+from, "b/"+file+"\t"+to, contents[0], contents[1], context)
+	if diff == "" {
+		fmt.Printf("No differences between %s and %s\n", from, to)
+		return
+	}
+	fmt.Print(diff)
 }
 
 func runRust(args []string) {
-	if len(args) != 2 {
-		fmt.Fprintln(os.Stderr, "usage: my-docs rust <crate> <symbol>")
+	var fixed, caseSensitive, words bool
+
+	var positionalArgs []string
+	for _, arg := range args {
+		switch arg {
+		case "--fixed-strings", "-F":
+			fixed = true
+		case "--case-sensitive", "-s":
+			caseSensitive = true
+		case "--word", "-w":
+			words = true
+		default:
+			positionalArgs = append(positionalArgs, arg)
+		}
+	}
+
+	if len(positionalArgs) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: my-docs rust <crate> <symbol> [-F] [-s] [-w]")
+		os.Exit(1)
+	}
+	crateName := positionalArgs[0]
+	symbol := positionalArgs[1]
+
+	q := grepapp.Query{
+		Pattern:       symbol,
+		FixedStrings:  fixed,
+		CaseSensitive: caseSensitive,
+		Words:         words,
+	}
+	if err := q.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
-	crateName := args[0]
-	symbol := args[1]
 
 	cfg := loadConfig()
 
@@ -234,7 +1408,7 @@ func runRust(args []string) {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
 			os.Exit(1)
 		}
-		repo, err = cratesio.ExtractGitHubRepo(resp)
+		repo, err = cratesio.ExtractRepo(resp, giteaHosts(cfg))
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
 			os.Exit(1)
@@ -244,23 +1418,39 @@ func runRust(args []string) {
 		saveConfig(cfg)
 	}
 
-	// Search for the symbol in the repo
-	resp, err := grepapp.Search(symbol, repo)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+	// Search for the symbol in the repo. grep.app only indexes GitHub, so
+	// repos elsewhere are searched in a local snapshot.
+	spec := parseSpecOrExit(repo)
+	provider := providerOrExit(spec)
+	var hits []grepapp.Hit
+	if spec.Host != "" {
+		var err error
+		hits, err = localSourceOrExit(q, []repospec.Spec{spec}, []forge.Provider{provider}, []grepapp.Query{q}, 0).Next()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		q.Repo = repo
+		resp, err := grepapp.Search(q)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		hits = resp.Hits.Hits
+		rememberBranches(hits, []repospec.Spec{spec})
 	}
 
-	if len(resp.Hits.Hits) == 0 {
+	if len(hits) == 0 {
 		fmt.Print(cmd.FormatNoMatches(symbol, crateName))
 		os.Exit(1)
 	}
 
-	files := cmd.CollectMatchingFiles(resp.Hits.Hits)
+	files := cmd.CollectMatchingFiles(hits)
 
 	if len(files) == 1 {
 		// Single file - fetch and output it
-		content, err := github.FetchFile(repo, files[0])
+		content, err := fetchHitFile(spec, provider, hits[0].Branch, files[0])
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
 			os.Exit(1)
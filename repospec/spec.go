@@ -0,0 +1,160 @@
+// ABOUTME: Parses repo references of the form [host/]owner/repo[@ref][//subdir].
+// ABOUTME: Every command takes one of these to name a repo, a version and a package within it.
+
+package repospec
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Spec is a parsed repo reference.
+type Spec struct {
+	// Host is the forge the repo lives on, e.g. "gitlab.com", or empty for
+	// GitHub.
+	Host string
+	// Repo is "owner/repo". Repos on other hosts may be nested in groups, as
+	// in "group/subgroup/project".
+	Repo string
+	// Ref is a branch, tag or commit SHA, or empty for the default branch.
+	Ref string
+	// Subdir scopes the spec to one directory, without leading or trailing
+	// slashes. Paths given alongside the spec are relative to it.
+	Subdir string
+}
+
+// Parse parses "owner/repo", optionally followed by "@ref" and then
+// "//subdir", as in "grafana/alloy@v1.4.0//docs/sources". A leading segment
+// containing a dot names the host, as in "gitlab.com/group/sub/project";
+// "github.com" is the same as no host.
+func Parse(s string) (Spec, error) {
+	rest, subdir, hasSubdir := strings.Cut(s, "//")
+	repo, ref, hasRef := strings.Cut(rest, "@")
+
+	var host string
+	if first, after, ok := strings.Cut(repo, "/"); ok && strings.Contains(first, ".") {
+		host, repo = strings.ToLower(first), after
+		if !validHost(host) {
+			return Spec{}, fmt.Errorf("invalid repo %q: malformed host %q", s, first)
+		}
+		if host == "github.com" {
+			host = ""
+		}
+	}
+
+	parts := strings.Split(repo, "/")
+	if len(parts) < 2 || (host == "" && len(parts) > 2) {
+		return Spec{}, fmt.Errorf("invalid repo %q: must be [host/]owner/repo[@ref][//subdir]", s)
+	}
+	for _, part := range parts {
+		if !validName(part) {
+			return Spec{}, fmt.Errorf("invalid repo %q: must be [host/]owner/repo[@ref][//subdir]", s)
+		}
+	}
+	if hasRef && ref == "" {
+		return Spec{}, fmt.Errorf("invalid repo %q: empty ref after @", s)
+	}
+	if strings.ContainsAny(ref, " \t~^:?*[\\") {
+		return Spec{}, fmt.Errorf("invalid repo %q: malformed ref %q", s, ref)
+	}
+
+	subdir = strings.Trim(subdir, "/")
+	if hasSubdir && subdir == "" {
+		return Spec{}, fmt.Errorf("invalid repo %q: empty subdirectory after //", s)
+	}
+	for _, part := range strings.Split(subdir, "/") {
+		if part == ".." || part == "." || (hasSubdir && part == "") {
+			return Spec{}, fmt.Errorf("invalid repo %q: malformed subdirectory %q", s, subdir)
+		}
+	}
+
+	return Spec{Host: host, Repo: repo, Ref: ref, Subdir: subdir}, nil
+}
+
+// validName reports whether s can be an owner, group or repo name. "." and
+// ".." are rejected: names end up in cache paths and API URLs.
+func validName(s string) bool {
+	if s == "" || s == "." || s == ".." {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// validHost reports whether s can be a host name, optionally with a port.
+func validHost(s string) bool {
+	name, port, hasPort := strings.Cut(s, ":")
+	if strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") || strings.Contains(name, "..") {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '.') {
+			return false
+		}
+	}
+	if hasPort {
+		if port == "" {
+			return false
+		}
+		for _, c := range port {
+			if c < '0' || c > '9' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Name is the repo with its host, e.g. "gitlab.com/group/project", or just
+// the repo for GitHub.
+func (s Spec) Name() string {
+	if s.Host == "" {
+		return s.Repo
+	}
+	return s.Host + "/" + s.Repo
+}
+
+// String formats the spec the way Parse accepts it.
+func (s Spec) String() string {
+	out := s.Name()
+	if s.Ref != "" {
+		out += "@" + s.Ref
+	}
+	if s.Subdir != "" {
+		out += "//" + s.Subdir
+	}
+	return out
+}
+
+// Join resolves a path relative to the spec's subdirectory into a path from
+// the repo root.
+func (s Spec) Join(p string) string {
+	if s.Subdir == "" {
+		return p
+	}
+	if p == "" {
+		return s.Subdir + "/"
+	}
+	joined := path.Join(s.Subdir, p)
+	if strings.HasSuffix(p, "/") {
+		joined += "/"
+	}
+	return joined
+}
+
+// Rel turns a path from the repo root into one relative to the spec's
+// subdirectory. Paths outside the subdirectory are returned unchanged.
+func (s Spec) Rel(p string) string {
+	if s.Subdir == "" {
+		return p
+	}
+	if rel, ok := strings.CutPrefix(p, s.Subdir+"/"); ok {
+		return rel
+	}
+	return p
+}
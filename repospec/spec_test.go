@@ -0,0 +1,107 @@
+// ABOUTME: Tests for repo spec parsing.
+// ABOUTME: Verifies refs, subdirectories and path translation relative to a subdirectory.
+
+package repospec
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Spec
+	}{
+		{"grafana/alloy", Spec{Repo: "grafana/alloy"}},
+		{"grafana/alloy@v1.4.0", Spec{Repo: "grafana/alloy", Ref: "v1.4.0"}},
+		{"grafana/alloy@release/1.4", Spec{Repo: "grafana/alloy", Ref: "release/1.4"}},
+		{"grafana/alloy//docs/sources", Spec{Repo: "grafana/alloy", Subdir: "docs/sources"}},
+		{"grafana/alloy//docs/", Spec{Repo: "grafana/alloy", Subdir: "docs"}},
+		{"grafana/alloy@4b825dc6//internal/component", Spec{Repo: "grafana/alloy", Ref: "4b825dc6", Subdir: "internal/component"}},
+		{"github.com/grafana/alloy", Spec{Repo: "grafana/alloy"}},
+		{"gitlab.com/gitlab-org/cli", Spec{Host: "gitlab.com", Repo: "gitlab-org/cli"}},
+		{"GitLab.example.com/group/sub/project@v2//docs", Spec{Host: "gitlab.example.com", Repo: "group/sub/project", Ref: "v2", Subdir: "docs"}},
+		{"git.corp:8443/team/project.git", Spec{Host: "git.corp:8443", Repo: "team/project.git"}},
+		{"owner/.github", Spec{Repo: "owner/.github"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, in := range []string{
+		"grafana",
+		"grafana/",
+		"/alloy",
+		"a/b/c",
+		"grafana/*",
+		"grafana/alloy@",
+		"grafana/alloy@v1 2",
+		"grafana/alloy//",
+		"grafana/alloy//../x",
+		"grafana/alloy//docs//x",
+		"github.com/a/b/c",
+		"gitlab.com/group",
+		"../../..@main",
+		"codeberg.org/../..@x",
+		"codeberg.org/owner/..",
+		"gitlab.com/group/./project",
+		"./alloy",
+		"grafana/.",
+		"..example.com/a/b",
+		"git.corp:/a/b",
+	} {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) error = nil, want error", in)
+		}
+	}
+}
+
+func TestSpecString(t *testing.T) {
+	for _, in := range []string{"grafana/alloy", "grafana/alloy@v1.4.0", "grafana/alloy@main//docs/sources", "gitlab.com/group/sub/project@v1"} {
+		spec, err := Parse(in)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", in, err)
+		}
+		if got := spec.String(); got != in {
+			t.Errorf("String() = %q, want %q", got, in)
+		}
+	}
+}
+
+func TestSpecJoinRel(t *testing.T) {
+	spec := Spec{Repo: "grafana/alloy", Subdir: "docs/sources"}
+
+	joins := []struct{ in, want string }{
+		{"", "docs/sources/"},
+		{"intro.md", "docs/sources/intro.md"},
+		{"reference/", "docs/sources/reference/"},
+	}
+	for _, tt := range joins {
+		if got := spec.Join(tt.in); got != tt.want {
+			t.Errorf("Join(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	rels := []struct{ in, want string }{
+		{"docs/sources/intro.md", "intro.md"},
+		{"docs/other.md", "docs/other.md"},
+	}
+	for _, tt := range rels {
+		if got := spec.Rel(tt.in); got != tt.want {
+			t.Errorf("Rel(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	if got := (Spec{Repo: "a/b"}).Join("x.md"); got != "x.md" {
+		t.Errorf("Join() without subdir = %q, want %q", got, "x.md")
+	}
+}
@@ -0,0 +1,166 @@
+// ABOUTME: Caches repository tarballs on disk for local searching.
+// ABOUTME: Reuses snapshots of branches for a day and evicts old snapshots to bound disk use.
+
+package snapshot
+
+import (
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TTL is how long a cached snapshot of a branch or tag is reused before it
+// is downloaded again. Snapshots of a commit SHA never change.
+const TTL = 24 * time.Hour
+
+// MaxAge and MaxSize bound the cache: snapshots unused for MaxAge are
+// deleted, and then the oldest until the rest fit in MaxSize bytes.
+const (
+	MaxAge  = 30 * 24 * time.Hour
+	MaxSize = 2 << 30
+)
+
+var commitSHA = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// Cache stores gzipped tarballs under Dir, one per repo and ref.
+type Cache struct {
+	Dir string
+
+	// now and maxSize are replaced in tests.
+	now     func() time.Time
+	maxSize int64
+}
+
+func NewCache(dir string) *Cache {
+	return &Cache{Dir: dir, now: time.Now, maxSize: MaxSize}
+}
+
+// DefaultCache returns the cache in the user's cache directory, for example
+// ~/.cache/my-docs/snapshots on Linux.
+func DefaultCache() (*Cache, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewCache(filepath.Join(dir, "my-docs", "snapshots")), nil
+}
+
+// Path is where the tarball of repo at ref is cached. Repos on hosts other
+// than GitHub are named with their host, e.g. "gitlab.com/group/project".
+func (c *Cache) Path(repo, ref string) string {
+	return filepath.Join(c.Dir, filepath.FromSlash(repo), url.PathEscape(ref)+".tar.gz")
+}
+
+// Fetch returns the path of the cached tarball of repo at ref, downloading it
+// with open first if it is missing or stale.
+func (c *Cache) Fetch(repo, ref string, open func() (io.ReadCloser, error)) (string, error) {
+	path := c.Path(repo, ref)
+	if info, err := os.Stat(path); err == nil {
+		if commitSHA.MatchString(ref) {
+			// Snapshots of commits never go stale, so their time records
+			// when they were last used, for Prune.
+			now := c.now()
+			os.Chtimes(path, now, now)
+			return path, nil
+		}
+		if c.now().Sub(info.ModTime()) < TTL {
+			return path, nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	body, err := open()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	// Download next to the final path and rename, so an interrupted download
+	// never leaves a truncated snapshot behind.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".download-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	// Eviction is best effort; a failure must not fail the search.
+	c.Prune(path)
+	return path, nil
+}
+
+// Prune deletes snapshots older than MaxAge, then the oldest remaining ones
+// until the cache holds at most MaxSize bytes, and removes directories left
+// empty. The snapshot at keep is never deleted.
+func (c *Cache) Prune(keep string) error {
+	type snapshot struct {
+		path string
+		size int64
+		mod  time.Time
+	}
+	var snapshots []snapshot
+	var dirs []string
+	err := filepath.WalkDir(c.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			dirs = append(dirs, path)
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		// Leave downloads in progress alone; old ones were interrupted.
+		if strings.HasPrefix(d.Name(), ".download-") && c.now().Sub(info.ModTime()) < TTL {
+			return nil
+		}
+		snapshots = append(snapshots, snapshot{path, info.Size(), info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(snapshots, func(a, b snapshot) int { return a.mod.Compare(b.mod) })
+	var total int64
+	for _, s := range snapshots {
+		total += s.size
+	}
+	now := c.now()
+	for _, s := range snapshots {
+		if s.path == keep || (now.Sub(s.mod) <= MaxAge && total <= c.maxSize) {
+			continue
+		}
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= s.size
+	}
+
+	// Deepest first, so parents emptied by their children go too. Removing a
+	// directory that is not empty fails, which is what keeps it.
+	for _, dir := range slices.Backward(dirs) {
+		if dir != c.Dir {
+			os.Remove(dir)
+		}
+	}
+	return nil
+}
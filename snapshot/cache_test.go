@@ -0,0 +1,123 @@
+// ABOUTME: Tests for the snapshot cache.
+// ABOUTME: Verifies download, reuse within the TTL, refresh of stale branch snapshots and eviction.
+
+package snapshot
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheFetch(t *testing.T) {
+	now := time.Now()
+	downloads := 0
+	c := NewCache(t.TempDir())
+	c.now = func() time.Time { return now }
+	open := func(ref string) func() (io.ReadCloser, error) {
+		return func() (io.ReadCloser, error) {
+			downloads++
+			return io.NopCloser(strings.NewReader("tarball " + ref)), nil
+		}
+	}
+
+	path, err := c.Fetch("owner/repo", "release/1.4", open("release/1.4"))
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if !strings.HasSuffix(path, "release%2F1.4.tar.gz") {
+		t.Errorf("Fetch() path = %q, want the ref escaped into one file name", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "tarball release/1.4" {
+		t.Errorf("cached file = %q, %v, want the downloaded body", data, err)
+	}
+
+	if _, err := c.Fetch("owner/repo", "release/1.4", open("release/1.4")); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if downloads != 1 {
+		t.Errorf("downloads = %d after a fresh hit, want 1", downloads)
+	}
+
+	now = now.Add(TTL + time.Hour)
+	if _, err := c.Fetch("owner/repo", "release/1.4", open("release/1.4")); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if downloads != 2 {
+		t.Errorf("downloads = %d after expiry, want 2", downloads)
+	}
+
+	sha := strings.Repeat("ab", 20)
+	c.Fetch("owner/repo", sha, open(sha))
+	c.Fetch("owner/repo", sha, open(sha))
+	if downloads != 3 {
+		t.Errorf("downloads = %d after refetching a commit, want 3", downloads)
+	}
+}
+
+func TestCachePrune(t *testing.T) {
+	now := time.Now()
+	c := NewCache(t.TempDir())
+	c.now = func() time.Time { return now }
+	c.maxSize = 250
+	write := func(repo, ref string, size int, age time.Duration) string {
+		path := c.Path(repo, ref)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mod := now.Add(-age)
+		if err := os.Chtimes(path, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	expired := write("old/repo", "main", 10, MaxAge+time.Hour)
+	oldest := write("big/repo", "v1", 100, 3*time.Hour)
+	older := write("big/repo", "v2", 100, 2*time.Hour)
+	kept := write("big/repo", "v3", 100, time.Hour)
+	fresh := write("new/repo", "main", 100, 0)
+
+	if err := c.Prune(fresh); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	for path, want := range map[string]bool{expired: false, oldest: false, older: false, kept: true, fresh: true} {
+		if _, err := os.Stat(path); (err == nil) != want {
+			t.Errorf("after Prune() %s exists = %v, want %v", path, err == nil, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(c.Dir, "old")); err == nil {
+		t.Error("Prune() left an empty directory behind")
+	}
+}
+
+func TestCacheFetch_TouchesCommits(t *testing.T) {
+	now := time.Now()
+	c := NewCache(t.TempDir())
+	c.now = func() time.Time { return now }
+	sha := strings.Repeat("cd", 20)
+	open := func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader("x")), nil }
+
+	path, err := c.Fetch("owner/repo", sha, open)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	now = now.Add(MaxAge - time.Hour)
+	if _, err := c.Fetch("owner/repo", sha, open); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if !info.ModTime().Equal(now) {
+		t.Errorf("ModTime() = %v after a cache hit, want %v", info.ModTime(), now)
+	}
+}
@@ -0,0 +1,200 @@
+// ABOUTME: Searches repository tarballs locally with Go regular expressions.
+// ABOUTME: Produces grep.app-shaped hits so search output works the same for both backends.
+
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bartriepe/my-docs/forge"
+	"github.com/bartriepe/my-docs/grepapp"
+)
+
+// maxFileSize is the largest file searched; bigger files are almost always
+// data rather than source or docs.
+const maxFileSize = 2 << 20
+
+// Target is one repo to search locally.
+type Target struct {
+	// Host is the repo's host, or empty for GitHub.
+	Host string
+	Repo string
+	Ref  string
+	// Path limits the search to files under this prefix, like grep.app's
+	// path filter.
+	Path string
+	// Provider downloads the repo's tarball.
+	Provider forge.Provider
+}
+
+// Name is the repo with its host, as hits and the cache name it.
+func (t Target) Name() string {
+	if t.Host == "" {
+		return t.Repo
+	}
+	return t.Host + "/" + t.Repo
+}
+
+// Source searches the snapshots of its targets and yields the matches as
+// hits. The first call to Next searches everything and returns all hits.
+type Source struct {
+	cache   *Cache
+	re      *regexp.Regexp
+	targets []Target
+	context int
+	hits    []grepapp.Hit
+	done    bool
+}
+
+// NewSource searches targets for re. Each hit includes up to context lines
+// around its matches.
+func NewSource(cache *Cache, re *regexp.Regexp, targets []Target, context int) *Source {
+	return &Source{cache: cache, re: re, targets: targets, context: context}
+}
+
+func (s *Source) Next() ([]grepapp.Hit, error) {
+	if s.done {
+		return nil, nil
+	}
+	for _, t := range s.targets {
+		path, err := s.cache.Fetch(t.Name(), t.Ref, func() (io.ReadCloser, error) {
+			return t.Provider.OpenTarball(t.Repo, t.Ref)
+		})
+		if err != nil {
+			return nil, err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		hits, err := SearchTarball(f, t, s.re, s.context)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		s.hits = append(s.hits, hits...)
+	}
+	s.done = true
+	return s.hits, nil
+}
+
+// Total returns the number of matching files, or zero before the search.
+func (s *Source) Total() int {
+	return len(s.hits)
+}
+
+// RepoCounts returns the number of matching files per repo.
+func (s *Source) RepoCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, h := range s.hits {
+		counts[h.Repo]++
+	}
+	return counts
+}
+
+// SearchTarball searches the files of a gzipped tarball of t for re, one hit
+// per matching file. The tarball's top-level directory is stripped from
+// paths. Binary files and files over 2 MiB are skipped.
+func SearchTarball(r io.Reader, t Target, re *regexp.Regexp, context int) ([]grepapp.Hit, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var hits []grepapp.Hit
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg || hdr.Size > maxFileSize {
+			continue
+		}
+		_, path, ok := strings.Cut(hdr.Name, "/")
+		if !ok || !strings.HasPrefix(path, t.Path) {
+			continue
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.IndexByte(content, 0) != -1 {
+			continue
+		}
+
+		lines, matched := SearchFile(string(content), re, context)
+		if matched == 0 {
+			continue
+		}
+		hits = append(hits, grepapp.Hit{
+			Repo:         t.Name(),
+			Branch:       t.Ref,
+			Path:         path,
+			TotalMatches: strconv.Itoa(matched),
+			Lines:        lines,
+		})
+	}
+	return hits, nil
+}
+
+// SearchFile returns the lines of content that match re, with up to context
+// unmatched lines around each, and the number of matching lines. Lines are
+// trimmed and their match ranges shifted the way grep.app snippets are.
+func SearchFile(content string, re *regexp.Regexp, context int) ([]grepapp.Match, int) {
+	rawLines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+
+	ranges := make(map[int][]grepapp.Range)
+	for i, raw := range rawLines {
+		for _, loc := range re.FindAllStringIndex(strings.TrimSuffix(raw, "\r"), -1) {
+			if loc[0] < loc[1] {
+				ranges[i] = append(ranges[i], grepapp.Range{Start: loc[0], End: loc[1]})
+			}
+		}
+	}
+	if len(ranges) == 0 {
+		return nil, 0
+	}
+
+	var lines []grepapp.Match
+	for i, raw := range rawLines {
+		near := false
+		for d := -context; d <= context && !near; d++ {
+			_, near = ranges[i+d]
+		}
+		if !near {
+			continue
+		}
+		lines = append(lines, trimLine(i+1, strings.TrimSuffix(raw, "\r"), ranges[i]))
+	}
+	return lines, len(ranges)
+}
+
+// trimLine trims raw and shifts its ranges to match.
+func trimLine(n int, raw string, ranges []grepapp.Range) grepapp.Match {
+	lead := len(raw) - len(strings.TrimLeftFunc(raw, unicode.IsSpace))
+	text := strings.TrimSpace(raw)
+	m := grepapp.Match{Line: n, Text: text}
+	for _, r := range ranges {
+		start := max(r.Start-lead, 0)
+		end := min(r.End-lead, len(text))
+		if start < end {
+			m.Ranges = append(m.Ranges, grepapp.Range{Start: start, End: end})
+		}
+	}
+	m.Matched = len(m.Ranges) > 0
+	return m
+}
@@ -0,0 +1,112 @@
+// ABOUTME: Tests for local tarball searching.
+// ABOUTME: Builds small tarballs in memory and checks hits, ranges, context and filtering.
+
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"regexp"
+	"testing"
+)
+
+// buildTarball returns a gzipped tarball of files under a codeload-style
+// top-level directory.
+func buildTarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeXGlobalHeader, Name: "pax_global_header", PAXRecords: map[string]string{"comment": "abc"}}); err != nil {
+		t.Fatalf("WriteHeader() error = %v", err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: "owner-repo-abc/", Mode: 0755}); err != nil {
+		t.Fatalf("WriteHeader() error = %v", err)
+	}
+	for _, name := range []string{"README.md", "docs/intro.md", "logo.png", "main.go"} {
+		content, ok := files[name]
+		if !ok {
+			continue
+		}
+		hdr := &tar.Header{Typeflag: tar.TypeReg, Name: "owner-repo-abc/" + name, Mode: 0644, Size: int64(len(content))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("WriteHeader() error = %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestSearchTarball(t *testing.T) {
+	data := buildTarball(t, map[string]string{
+		"README.md":     "# Demo\nRun the exporter.\n",
+		"docs/intro.md": "intro\n\n  The exporter exports.\n",
+		"logo.png":      "\x89PNG\x00exporter",
+		"main.go":       "package main\n",
+	})
+	re := regexp.MustCompile(`(?i)exporter`)
+
+	hits, err := SearchTarball(bytes.NewReader(data), Target{Repo: "owner/repo", Ref: "main"}, re, 0)
+	if err != nil {
+		t.Fatalf("SearchTarball() error = %v", err)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("SearchTarball() = %d hits, want 2", len(hits))
+	}
+	if hits[0].Path != "README.md" || hits[1].Path != "docs/intro.md" {
+		t.Errorf("SearchTarball() paths = %q, %q, want README.md, docs/intro.md", hits[0].Path, hits[1].Path)
+	}
+	if hits[0].Repo != "owner/repo" || hits[0].Branch != "main" || hits[0].TotalMatches != "1" {
+		t.Errorf("SearchTarball()[0] = %+v, want repo, ref and one match", hits[0])
+	}
+
+	got := hits[1].Matches()
+	if len(got) != 1 || got[0].Line != 3 || got[0].Text != "The exporter exports." {
+		t.Fatalf("hit lines = %+v, want line 3 trimmed", got)
+	}
+	if len(got[0].Ranges) != 1 || got[0].Ranges[0].Start != 4 || got[0].Ranges[0].End != 12 {
+		t.Errorf("hit ranges = %+v, want [{4 12}]", got[0].Ranges)
+	}
+
+	scoped, err := SearchTarball(bytes.NewReader(data), Target{Repo: "owner/repo", Ref: "main", Path: "docs/"}, re, 0)
+	if err != nil {
+		t.Fatalf("SearchTarball() error = %v", err)
+	}
+	if len(scoped) != 1 || scoped[0].Path != "docs/intro.md" {
+		t.Errorf("SearchTarball() under docs/ = %+v, want docs/intro.md only", scoped)
+	}
+}
+
+func TestSearchFile_Context(t *testing.T) {
+	content := "a\nb\nmatch\nc\nd\ne\nmatch\n"
+	lines, n := SearchFile(content, regexp.MustCompile("match"), 1)
+	if n != 2 {
+		t.Errorf("SearchFile() matched %d lines, want 2", n)
+	}
+
+	var got []int
+	for _, l := range lines {
+		got = append(got, l.Line)
+	}
+	want := []int{2, 3, 4, 6, 7}
+	if len(got) != len(want) {
+		t.Fatalf("SearchFile() lines = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("SearchFile() lines = %v, want %v", got, want)
+		}
+	}
+	if lines[0].Matched || !lines[1].Matched {
+		t.Errorf("SearchFile() Matched flags = %v, %v, want false, true", lines[0].Matched, lines[1].Matched)
+	}
+}
@@ -0,0 +1,258 @@
+// ABOUTME: Computes line diffs with the Myers algorithm and prints them as unified diffs.
+// ABOUTME: Used to compare a file between two refs without cloning the repo.
+
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OpKind says what an edit does to a line.
+type OpKind int
+
+const (
+	Equal OpKind = iota
+	Delete
+	Insert
+)
+
+// Edit is one step of turning a into b. A and B are the positions in a and b
+// the edit applies at; Line is the line kept, deleted or inserted.
+type Edit struct {
+	Kind OpKind
+	A, B int
+	Line string
+}
+
+// Lines returns a shortest edit script from a to b, using the linear-space
+// form of Myers' O(ND) algorithm: it finds the middle snake of an optimal
+// path and recurses on both sides of it, so memory stays O(N+M) however
+// different the inputs are. Within each change, deletions come before
+// insertions.
+func Lines(a, b []string) []Edit {
+	size := 2*(len(a)+len(b)) + 3
+	d := &differ{a: a, b: b, vf: make([]int, size), vb: make([]int, size)}
+	d.compare(0, len(a), 0, len(b))
+	return groupChanges(d.edits)
+}
+
+// differ holds the inputs, the frontiers reused by every middleSnake call
+// and the edits found so far, in order.
+type differ struct {
+	a, b   []string
+	vf, vb []int
+	edits  []Edit
+}
+
+// compare appends the edits turning a[aLo:aHi] into b[bLo:bHi].
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.edits = append(d.edits, Edit{Kind: Equal, A: aLo, B: bLo, Line: d.a[aLo]})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aHi-suffix > aLo && bHi-suffix > bLo && d.a[aHi-suffix-1] == d.b[bHi-suffix-1] {
+		suffix++
+	}
+	aMid, bMid := aHi-suffix, bHi-suffix
+
+	switch {
+	case aLo == aMid:
+		for y := bLo; y < bMid; y++ {
+			d.edits = append(d.edits, Edit{Kind: Insert, A: aLo, B: y, Line: d.b[y]})
+		}
+	case bLo == bMid:
+		for x := aLo; x < aMid; x++ {
+			d.edits = append(d.edits, Edit{Kind: Delete, A: x, B: bLo, Line: d.a[x]})
+		}
+	default:
+		// With the common ends stripped and both sides non-empty, at least
+		// two edits remain, so both halves are smaller problems.
+		x, y, u, v := d.middleSnake(aLo, aMid, bLo, bMid)
+		d.compare(aLo, x, bLo, y)
+		for ; x < u; x, y = x+1, y+1 {
+			d.edits = append(d.edits, Edit{Kind: Equal, A: x, B: y, Line: d.a[x]})
+		}
+		d.compare(u, aMid, v, bMid)
+	}
+
+	for i := range suffix {
+		x, y := aMid+i, bMid+i
+		d.edits = append(d.edits, Edit{Kind: Equal, A: x, B: y, Line: d.a[x]})
+	}
+}
+
+// middleSnake finds the snake, a run of equal lines from (x, y) to (u, v),
+// at the middle of a shortest path from a[aLo:aHi] to b[bLo:bHi], by
+// searching forward from the start and backward from the end at once.
+// Diagonal k holds the points where x - y = k; vf[k] is the furthest x
+// reached on it going forward, vb[k] the furthest distance from the end
+// going backward.
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	half := (n + m + 1) / 2
+	off := half + 1
+	vf, vb := d.vf, d.vb
+	vf[off+1], vb[off+1] = 0, 0
+
+	for D := 0; D <= half; D++ {
+		for k := -D; k <= D; k += 2 {
+			var x int
+			if k == -D || (k != D && vf[off+k-1] < vf[off+k+1]) {
+				x = vf[off+k+1]
+			} else {
+				x = vf[off+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			vf[off+k] = x
+			if kb := delta - k; odd && kb >= -(D-1) && kb <= D-1 && x+vb[off+kb] >= n {
+				return aLo + x0, bLo + y0, aLo + x, bLo + y
+			}
+		}
+		for k := -D; k <= D; k += 2 {
+			var x int
+			if k == -D || (k != D && vb[off+k-1] < vb[off+k+1]) {
+				x = vb[off+k+1]
+			} else {
+				x = vb[off+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && d.a[aHi-1-x] == d.b[bHi-1-y] {
+				x++
+				y++
+			}
+			vb[off+k] = x
+			if kf := delta - k; !odd && kf >= -D && kf <= D && x+vf[off+kf] >= n {
+				return aHi - x, bHi - y, aHi - x0, bHi - y0
+			}
+		}
+	}
+	panic("textdiff: no middle snake")
+}
+
+// groupChanges reorders each run of changes between equal lines so its
+// deletions come first, as diff prints them, and renumbers the positions.
+func groupChanges(edits []Edit) []Edit {
+	out := make([]Edit, 0, len(edits))
+	for i := 0; i < len(edits); {
+		if edits[i].Kind == Equal {
+			out = append(out, edits[i])
+			i++
+			continue
+		}
+		start := i
+		for i < len(edits) && edits[i].Kind != Equal {
+			i++
+		}
+		run := edits[start:i]
+		// The run starts where the first change applies.
+		x, y := run[0].A, run[0].B
+		for _, e := range run {
+			if e.Kind == Delete {
+				out = append(out, Edit{Kind: Delete, A: x, B: y, Line: e.Line})
+				x++
+			}
+		}
+		for _, e := range run {
+			if e.Kind == Insert {
+				out = append(out, Edit{Kind: Insert, A: x, B: y, Line: e.Line})
+				y++
+			}
+		}
+	}
+	return out
+}
+
+// SplitLines splits text into lines that keep their newlines, so a last line
+// without one differs from the same line with one.
+func SplitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Unified diffs a and b and prints the result as a unified diff with context
+// lines around each change, under headers naming the two sides. It returns
+// an empty string when the texts are equal.
+func Unified(aName, bName, a, b string, context int) string {
+	edits := Lines(SplitLines(a), SplitLines(b))
+
+	var sb strings.Builder
+	for i := 0; i < len(edits); {
+		if edits[i].Kind == Equal {
+			i++
+			continue
+		}
+		// A hunk runs from context lines before this change to context lines
+		// after the last change that is within 2*context lines of the next.
+		start := max(i-context, 0)
+		end := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].Kind != Equal {
+				end = j + 1
+				continue
+			}
+			if j-end >= 2*context {
+				break
+			}
+		}
+		end = min(end+context, len(edits))
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+		}
+		writeHunk(&sb, edits[start:end])
+		i = end
+	}
+	return sb.String()
+}
+
+func writeHunk(sb *strings.Builder, hunk []Edit) {
+	var aCount, bCount int
+	for _, e := range hunk {
+		if e.Kind != Insert {
+			aCount++
+		}
+		if e.Kind != Delete {
+			bCount++
+		}
+	}
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(hunk[0].A, aCount), hunkRange(hunk[0].B, bCount))
+	for _, e := range hunk {
+		prefix := " "
+		switch e.Kind {
+		case Delete:
+			prefix = "-"
+		case Insert:
+			prefix = "+"
+		}
+		sb.WriteString(prefix + e.Line)
+		if !strings.HasSuffix(e.Line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats a hunk's 0-based start and line count the way diff
+// does: 1-based, and naming the line before the hunk when it is empty.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
@@ -0,0 +1,163 @@
+// ABOUTME: Tests for the Myers diff and unified diff output.
+// ABOUTME: Verifies minimal edit scripts, hunk grouping and missing final newlines.
+
+package textdiff
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"", "", ""},
+		{"abc", "abc", "=a =b =c"},
+		{"", "ab", "+a +b"},
+		{"ab", "", "-a -b"},
+		{"axb", "ayb", "=a -x +y =b"},
+	}
+	for _, tt := range tests {
+		edits := Lines(strings.Split(tt.a, "")[:len(tt.a)], strings.Split(tt.b, "")[:len(tt.b)])
+		var got []string
+		for _, e := range edits {
+			got = append(got, map[OpKind]string{Equal: "=", Delete: "-", Insert: "+"}[e.Kind]+e.Line)
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("Lines(%q, %q) = %q, want %q", tt.a, tt.b, strings.Join(got, " "), tt.want)
+		}
+	}
+}
+
+// checkEdits reports whether edits turn a into b with the fewest changes,
+// as counted from a longest common subsequence table.
+func checkEdits(t *testing.T, a, b []string, edits []Edit) {
+	t.Helper()
+	var gotA, gotB []string
+	changes := 0
+	for _, e := range edits {
+		if e.Kind != Insert {
+			gotA = append(gotA, e.Line)
+		}
+		if e.Kind != Delete {
+			gotB = append(gotB, e.Line)
+		}
+		if e.Kind != Equal {
+			changes++
+		}
+	}
+	if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+		t.Fatalf("Lines(%q, %q) does not turn a into b", a, b)
+	}
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	if want := len(a) + len(b) - 2*lcs[0][0]; changes != want {
+		t.Errorf("Lines(%q, %q) makes %d changes, want %d", a, b, changes, want)
+	}
+}
+
+func TestLines_Shortest(t *testing.T) {
+	// The example from Myers' paper has edit distance 5.
+	checkEdits(t, strings.Split("abcabba", ""), strings.Split("cbabac", ""), Lines(strings.Split("abcabba", ""), strings.Split("cbabac", "")))
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	random := func() []string {
+		lines := make([]string, rng.IntN(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.IntN(4)))
+		}
+		return lines
+	}
+	for range 500 {
+		a, b := random(), random()
+		checkEdits(t, a, b, Lines(a, b))
+	}
+}
+
+func TestLines_LinearMemory(t *testing.T) {
+	a := make([]string, 5000)
+	b := make([]string, 5000)
+	for i := range a {
+		a[i] = fmt.Sprintf("old %d\n", i)
+		b[i] = fmt.Sprintf("new %d\n", i)
+	}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	edits := Lines(a, b)
+	runtime.ReadMemStats(&after)
+
+	if len(edits) != 10000 {
+		t.Errorf("Lines() of disjoint files = %d edits, want 10000", len(edits))
+	}
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 16<<20 {
+		t.Errorf("Lines() of two 5000-line files allocated %d bytes, want under 16 MiB", alloc)
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	if got := SplitLines("a\nb"); len(got) != 2 || got[1] != "b" {
+		t.Errorf("SplitLines(\"a\\nb\") = %q, want [\"a\\n\" \"b\"]", got)
+	}
+	if got := SplitLines("a\n"); len(got) != 1 {
+		t.Errorf("SplitLines(\"a\\n\") = %q, want one line", got)
+	}
+	if got := SplitLines(""); len(got) != 0 {
+		t.Errorf("SplitLines(\"\") = %q, want none", got)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\ntwelve\n"
+	b := "one\n2\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\ntwelve\nthirteen\n"
+	want := "--- a/x (v1)\n+++ b/x (v2)\n" +
+		"@@ -1,5 +1,5 @@\n one\n-two\n+2\n three\n four\n five\n" +
+		"@@ -10,3 +10,4 @@\n ten\n eleven\n twelve\n+thirteen\n"
+	if got := Unified("a/x (v1)", "b/x (v2)", a, b, 3); got != want {
+		t.Errorf("Unified() = %q, want %q", got, want)
+	}
+
+	// Changes within twice the context share a hunk.
+	b = "one\n2\nthree\nfour\nfive\nsix\n7\neight\nnine\nten\neleven\ntwelve\n"
+	want = "--- a\n+++ b\n@@ -1,10 +1,10 @@\n one\n-two\n+2\n three\n four\n five\n six\n-seven\n+7\n eight\n nine\n ten\n"
+	if got := Unified("a", "b", a, b, 3); got != want {
+		t.Errorf("Unified() with nearby changes = %q, want %q", got, want)
+	}
+
+	if got := Unified("a", "b", a, a, 3); got != "" {
+		t.Errorf("Unified() of equal texts = %q, want empty", got)
+	}
+}
+
+func TestUnified_EdgeCases(t *testing.T) {
+	tests := []struct {
+		name, a, b, want string
+	}{
+		{"new file", "", "x\n", "--- a\n+++ b\n@@ -0,0 +1 @@\n+x\n"},
+		{"emptied file", "x\ny\n", "", "--- a\n+++ b\n@@ -1,2 +0,0 @@\n-x\n-y\n"},
+		{"no final newline", "x\ny", "x\ny\n", "--- a\n+++ b\n@@ -1,2 +1,2 @@\n x\n-y\n\\ No newline at end of file\n+y\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified("a", "b", tt.a, tt.b, 3); got != tt.want {
+				t.Errorf("Unified() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}